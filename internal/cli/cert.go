@@ -0,0 +1,118 @@
+package cli
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "fmt"
+    "math/big"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/spf13/cobra"
+)
+
+type certOptions struct {
+    Dir   string
+    Hosts []string
+    Days  int
+    Force bool
+}
+
+func newCertDevCmd() *cobra.Command {
+    opts := certOptions{Dir: "certs", Hosts: []string{"localhost", "127.0.0.1", "::1"}, Days: 365}
+    cmd := &cobra.Command{
+        Use:   "cert:dev",
+        Short: "Generate a self-signed TLS certificate for local development",
+        Long:  "Generate a self-signed ECDSA certificate and key (dev.crt/dev.key) for serving HTTPS locally via TLS_CERT_FILE/TLS_KEY_FILE. Browsers will warn about it; never use it in production.",
+        Args:  cobra.NoArgs,
+        RunE: func(cmd *cobra.Command, args []string) error {
+            return runCertDev(cmd, opts)
+        },
+        Example: "  largo cert:dev\n  largo cert:dev --host myapp.test --days 30",
+    }
+    cmd.Flags().StringVar(&opts.Dir, "dir", opts.Dir, "Output directory")
+    cmd.Flags().StringSliceVar(&opts.Hosts, "host", opts.Hosts, "DNS names / IPs the certificate is valid for")
+    cmd.Flags().IntVar(&opts.Days, "days", opts.Days, "Validity in days")
+    cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Overwrite existing files")
+    return cmd
+}
+
+func runCertDev(cmd *cobra.Command, opts certOptions) error {
+    certPath := filepath.Join(opts.Dir, "dev.crt")
+    keyPath := filepath.Join(opts.Dir, "dev.key")
+    if !opts.Force {
+        for _, p := range []string{certPath, keyPath} {
+            if _, err := os.Stat(p); err == nil {
+                return fmt.Errorf("file exists: %s (use --force to overwrite)", p)
+            }
+        }
+    }
+    if opts.Days <= 0 {
+        return fmt.Errorf("--days must be positive")
+    }
+    var hosts []string
+    for _, h := range opts.Hosts {
+        if h = strings.TrimSpace(h); h != "" {
+            hosts = append(hosts, h)
+        }
+    }
+    if len(hosts) == 0 {
+        return fmt.Errorf("--host needs at least one DNS name or IP")
+    }
+
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        return err
+    }
+    serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    tmpl := &x509.Certificate{
+        SerialNumber:          serial,
+        Subject:               pkix.Name{Organization: []string{"LarGo development"}, CommonName: hosts[0]},
+        NotBefore:             now.Add(-time.Hour),
+        NotAfter:              now.AddDate(0, 0, opts.Days),
+        KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+        ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    for _, h := range hosts {
+        if ip := net.ParseIP(h); ip != nil {
+            tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+        } else {
+            tmpl.DNSNames = append(tmpl.DNSNames, h)
+        }
+    }
+    der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+    if err != nil {
+        return fmt.Errorf("create certificate: %w", err)
+    }
+    keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+    if err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+        return err
+    }
+    if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+        return err
+    }
+    if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+        return err
+    }
+    out := cmd.OutOrStdout()
+    fmt.Fprintf(out, "Created %s\nCreated %s\n", certPath, keyPath)
+    fmt.Fprintf(out, "\nAdd to .env:\n  TLS_CERT_FILE=%s\n  TLS_KEY_FILE=%s\n", certPath, keyPath)
+    return nil
+}
@@ -3,14 +3,18 @@ package cli
 import (
     "bufio"
     "context"
+    "crypto/sha256"
     "database/sql"
+    "encoding/hex"
     "errors"
     "fmt"
     "io/fs"
     "os"
+    "os/signal"
     "path/filepath"
     "sort"
     "strings"
+    "syscall"
     "time"
 
     "github.com/joho/godotenv"
@@ -21,35 +25,45 @@ import (
 type migrateOptions struct {
     Dir         string
     DatabaseURL string
+    LockTimeout time.Duration
+    AllowDrift  bool
+    TxMode      string
 }
 
 func newMigrateCmd() *cobra.Command {
-    opts := migrateOptions{Dir: "internal/db/migrations"}
+    opts := migrateOptions{Dir: "internal/db/migrations", LockTimeout: time.Minute, TxMode: txBatch}
     cmd := &cobra.Command{
         Use:   "migrate",
         Short: "Apply all pending database migrations",
         RunE: func(cmd *cobra.Command, args []string) error {
-            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB) error {
-                return migrateUp(ctx, cmd, db, opts.Dir)
+            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB, d dialect) error {
+                return withMigrationLock(ctx, cmd, db, d, opts.LockTimeout, func() error {
+                    return migrateUp(ctx, cmd, db, d, opts)
+                })
             })
         },
     }
     addMigrateFlags(cmd, &opts)
+    addRunFlags(cmd, &opts)
+    cmd.Flags().BoolVar(&opts.AllowDrift, "allow-drift", false, "Apply pending migrations even if applied ones were modified or deleted")
     return cmd
 }
 
 func newMigrateRollbackCmd() *cobra.Command {
-    opts := migrateOptions{Dir: "internal/db/migrations"}
+    opts := migrateOptions{Dir: "internal/db/migrations", LockTimeout: time.Minute, TxMode: txBatch}
     cmd := &cobra.Command{
         Use:   "migrate:rollback",
         Short: "Rollback the last migration batch",
         RunE: func(cmd *cobra.Command, args []string) error {
-            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB) error {
-                return migrateRollback(ctx, cmd, db, opts.Dir)
+            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB, d dialect) error {
+                return withMigrationLock(ctx, cmd, db, d, opts.LockTimeout, func() error {
+                    return migrateRollback(ctx, cmd, db, d, opts)
+                })
             })
         },
     }
     addMigrateFlags(cmd, &opts)
+    addRunFlags(cmd, &opts)
     return cmd
 }
 
@@ -59,8 +73,8 @@ func newMigrateStatusCmd() *cobra.Command {
         Use:   "migrate:status",
         Short: "Show migration status",
         RunE: func(cmd *cobra.Command, args []string) error {
-            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB) error {
-                return migrateStatus(ctx, cmd, db, opts.Dir)
+            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB, d dialect) error {
+                return migrateStatus(ctx, cmd, db, d, opts.Dir)
             })
         },
     }
@@ -68,12 +82,183 @@ func newMigrateStatusCmd() *cobra.Command {
     return cmd
 }
 
+func newMigrateUnlockCmd() *cobra.Command {
+    opts := migrateOptions{Dir: "internal/db/migrations"}
+    cmd := &cobra.Command{
+        Use:   "migrate:unlock",
+        Short: "Clear a migration lock left behind by a crashed run",
+        Long:  "Clear the migration lock row left by a run that crashed (SQLite). Postgres and MySQL locks are released when the holding session ends, so there is nothing to clear. Make sure no migration is actually running first.",
+        RunE: func(cmd *cobra.Command, args []string) error {
+            return withDB(cmd, opts, func(ctx context.Context, db *sql.DB, d dialect) error {
+                u, ok := d.(lockClearer)
+                if !ok {
+                    fmt.Fprintf(cmd.OutOrStdout(), "%s migration locks are released when the holding session ends; nothing to clear.\n", d.Name())
+                    return nil
+                }
+                holder, err := u.ClearLock(ctx, db)
+                if err != nil {
+                    return fmt.Errorf("clear migration lock: %w", err)
+                }
+                if holder == "" {
+                    fmt.Fprintln(cmd.OutOrStdout(), "No migration lock held.")
+                    return nil
+                }
+                fmt.Fprintf(cmd.OutOrStdout(), "Cleared migration lock held by %s.\n", holder)
+                return nil
+            })
+        },
+    }
+    cmd.Flags().StringVar(&opts.DatabaseURL, "database-url", "", "Database URL (overrides env DATABASE_URL)")
+    return cmd
+}
+
 func addMigrateFlags(cmd *cobra.Command, opts *migrateOptions) {
     cmd.Flags().StringVar(&opts.Dir, "dir", opts.Dir, "Migrations directory")
     cmd.Flags().StringVar(&opts.DatabaseURL, "database-url", "", "Database URL (overrides env DATABASE_URL)")
 }
 
-func withDB(cmd *cobra.Command, opts migrateOptions, fn func(context.Context, *sql.DB) error) error {
+// addRunFlags adds the flags shared by commands that change the schema.
+func addRunFlags(cmd *cobra.Command, opts *migrateOptions) {
+    cmd.Flags().DurationVar(&opts.LockTimeout, "lock-timeout", opts.LockTimeout, "How long to wait for another migration run to finish (0 = fail if locked)")
+    cmd.Flags().StringVar(&opts.TxMode, "tx-mode", opts.TxMode, "Transactions: batch (one for all), each (one per migration) or none; files with '"+noTxDirective+"' always run without one")
+}
+
+// dialect isolates what differs between databases: how to connect, the
+// schema_migrations DDL and placeholder syntax. Queries are written with ?
+// placeholders and passed through Rebind.
+type dialect interface {
+    Name() string
+    Open(dsn string) (*sql.DB, error)
+    MigrationsTableSQL() string
+    Rebind(query string) string
+    // TransactionalDDL reports whether DDL can be rolled back, so a whole
+    // batch can run in one transaction. When false migrations run statement
+    // by statement with dirty tracking, as with --tx-mode=none.
+    TransactionalDDL() bool
+    // BackslashEscapes reports whether a backslash escapes the next
+    // character inside quoted strings (MySQL), which matters when splitting
+    // no-transaction migrations into statements.
+    BackslashEscapes() bool
+    // TryLock makes one attempt at the database-wide migration lock,
+    // returning ok=false if another process holds it. release must be
+    // called once done.
+    TryLock(ctx context.Context, db *sql.DB, holder string) (release func(), ok bool, err error)
+    // LockHolder describes who holds the lock, or "" if unknown.
+    LockHolder(ctx context.Context, db *sql.DB) string
+}
+
+// lockClearer is implemented by dialects whose migration lock outlives a
+// crashed process and so may need clearing by hand (migrate:unlock).
+type lockClearer interface {
+    // ClearLock removes the lock and returns its former holder, or "" if
+    // it was not held.
+    ClearLock(ctx context.Context, db *sql.DB) (string, error)
+}
+
+// dialectFor picks the dialect from the DSN scheme.
+func dialectFor(dsn string) (dialect, error) {
+    switch {
+    case isPostgres(dsn):
+        return postgresDialect{}, nil
+    case isSQLite(dsn):
+        return sqliteDialect{}, nil
+    case isMySQL(dsn):
+        return mysqlDialect{}, nil
+    }
+    return nil, fmt.Errorf("unsupported database URL (want postgres://, mysql://, sqlite:// or file:): %s", redactDSN(dsn))
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Open(dsn string) (*sql.DB, error) {
+    // pgx registers as driver name "pgx"
+    db, err := sql.Open("pgx", dsn)
+    if err != nil {
+        return nil, err
+    }
+    db.SetMaxOpenConns(10)
+    db.SetMaxIdleConns(5)
+    db.SetConnMaxLifetime(30 * time.Minute)
+    return db, nil
+}
+
+func (postgresDialect) MigrationsTableSQL() string {
+    return `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            name       TEXT PRIMARY KEY,
+            batch      INTEGER NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+            checksum   TEXT,
+            dirty      BOOLEAN NOT NULL DEFAULT FALSE
+        )
+    `
+}
+
+func (postgresDialect) TransactionalDDL() bool { return true }
+
+func (postgresDialect) BackslashEscapes() bool { return false }
+
+// pgLockKey is the advisory lock key for migrations ("larg"). It fits in 32
+// bits so it shows up as objid in pg_locks.
+const pgLockKey int64 = 0x6c617267
+
+// TryLock takes a session-level advisory lock on a dedicated connection,
+// tagged via application_name so waiters can see who holds it.
+func (postgresDialect) TryLock(ctx context.Context, db *sql.DB, holder string) (func(), bool, error) {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return nil, false, err
+    }
+    var ok bool
+    if _, err = conn.ExecContext(ctx, `SELECT set_config('application_name', $1, false)`, holder); err == nil {
+        err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, pgLockKey).Scan(&ok)
+    }
+    if err != nil || !ok {
+        conn.Close()
+        return nil, false, err
+    }
+    return func() {
+        _, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, pgLockKey)
+        conn.Close()
+    }, true, nil
+}
+
+func (postgresDialect) LockHolder(ctx context.Context, db *sql.DB) string {
+    var (
+        pid       int
+        app, addr string
+        since     time.Time
+    )
+    err := db.QueryRowContext(ctx, `
+        SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), 'local'), a.backend_start
+        FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+        WHERE l.locktype = 'advisory' AND l.granted AND l.classid = 0 AND l.objid::bigint = $1
+          AND l.database = (SELECT oid FROM pg_database WHERE datname = current_database())
+        LIMIT 1`, pgLockKey).Scan(&pid, &app, &addr, &since)
+    if err != nil {
+        return ""
+    }
+    return fmt.Sprintf("%q (backend pid %d from %s, connected %s)", app, pid, addr, since.Format(time.RFC3339))
+}
+
+// Rebind turns ? placeholders into $1, $2, ...
+func (postgresDialect) Rebind(query string) string {
+    var b strings.Builder
+    n := 0
+    for _, r := range query {
+        if r == '?' {
+            n++
+            fmt.Fprintf(&b, "$%d", n)
+            continue
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
+
+func withDB(cmd *cobra.Command, opts migrateOptions, fn func(context.Context, *sql.DB, dialect) error) error {
     // Load .env if present (ignore errors)
     _ = godotenv.Load()
 
@@ -84,49 +269,112 @@ func withDB(cmd *cobra.Command, opts migrateOptions, fn func(context.Context, *s
     if dsn == "" {
         return errors.New("DATABASE_URL is not set; pass --database-url or set in .env")
     }
-    if !isPostgres(dsn) {
-        return fmt.Errorf("unsupported database URL (only postgres is supported for now): %s", redactDSN(dsn))
+    d, err := dialectFor(dsn)
+    if err != nil {
+        return err
     }
-
-    // pgx registers as driver name "pgx"
-    db, err := sql.Open("pgx", dsn)
+    db, err := d.Open(dsn)
     if err != nil {
         return err
     }
     defer db.Close()
-    db.SetMaxOpenConns(10)
-    db.SetMaxIdleConns(5)
-    db.SetConnMaxLifetime(30 * time.Minute)
 
-    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-    defer cancel()
+    // Ctrl-C cancels cleanly, releasing the migration lock
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
 
-    if err := db.PingContext(ctx); err != nil {
+    pingCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+    defer cancel()
+    if err := db.PingContext(pingCtx); err != nil {
         return fmt.Errorf("database connection failed: %w", err)
     }
+    return fn(ctx, db, d)
+}
 
-    if err := ensureMigrationsTable(ctx, db); err != nil {
+// ensureMigrationsTable creates schema_migrations or upgrades one from an
+// older version. It must run under the migration lock so concurrent runs
+// don't race on the ALTERs.
+func ensureMigrationsTable(ctx context.Context, db *sql.DB, d dialect) error {
+    if _, err := db.ExecContext(ctx, d.MigrationsTableSQL()); err != nil {
         return err
     }
-    return fn(ctx, db)
+    // tables from older versions: add missing columns in place
+    for _, col := range []struct{ name, def string }{
+        {"checksum", "VARCHAR(64)"},
+        {"dirty", "BOOLEAN NOT NULL DEFAULT FALSE"},
+    } {
+        if hasMigrationsColumn(ctx, db, col.name) {
+            continue
+        }
+        if _, err := db.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN `+col.name+` `+col.def); err != nil {
+            return fmt.Errorf("add %s column to schema_migrations: %w", col.name, err)
+        }
+    }
+    return nil
 }
 
-func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
-    _, err := db.ExecContext(ctx, `
-        CREATE TABLE IF NOT EXISTS schema_migrations (
-            name       TEXT PRIMARY KEY,
-            batch      INTEGER NOT NULL,
-            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
-        )
-    `)
-    return err
+// hasMigrationsColumn reports whether schema_migrations has col, without
+// changing anything; probing "name" tells whether the table exists at all.
+func hasMigrationsColumn(ctx context.Context, db *sql.DB, col string) bool {
+    rows, err := db.QueryContext(ctx, `SELECT `+col+` FROM schema_migrations WHERE 1 = 0`)
+    if err != nil {
+        return false
+    }
+    rows.Close()
+    return true
+}
+
+// withMigrationLock runs fn while holding the dialect's migration lock,
+// polling for up to timeout if another process holds it and reporting the
+// holder while waiting.
+func withMigrationLock(ctx context.Context, cmd *cobra.Command, db *sql.DB, d dialect, timeout time.Duration, fn func() error) error {
+    holder := lockHolderID()
+    deadline := time.Now().Add(timeout)
+    waitingOn := ""
+    for {
+        release, ok, err := d.TryLock(ctx, db, holder)
+        if err != nil {
+            return fmt.Errorf("acquire migration lock: %w", err)
+        }
+        if ok {
+            defer release()
+            if err := ensureMigrationsTable(ctx, db, d); err != nil {
+                return err
+            }
+            return fn()
+        }
+        h := d.LockHolder(ctx, db)
+        if h == "" {
+            h = "another process"
+        }
+        if !time.Now().Before(deadline) {
+            return fmt.Errorf("migration lock still held by %s after %s (see --lock-timeout)", h, timeout)
+        }
+        if h != waitingOn {
+            fmt.Fprintf(cmd.ErrOrStderr(), "Waiting for migration lock held by %s...\n", h)
+            waitingOn = h
+        }
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-time.After(500 * time.Millisecond):
+        }
+    }
+}
+
+// lockHolderID identifies this process to other migration runs.
+func lockHolderID() string {
+    host, _ := os.Hostname()
+    return fmt.Sprintf("largo migrate on %s pid %d", host, os.Getpid())
 }
 
 type migrationFile struct {
-    Name   string
-    Path   string
-    UpSQL  string
-    DownSQL string
+    Name     string
+    Path     string
+    UpSQL    string
+    DownSQL  string
+    Checksum string // sha256 of the file, line endings normalized
+    NoTx     bool   // file has the largo:no-transaction directive
 }
 
 func readMigrations(dir string) ([]migrationFile, error) {
@@ -167,8 +415,17 @@ func parseMigrationFile(path string) (migrationFile, error) {
         return migrationFile{}, err
     }
     name := filepath.Base(path)
-    up, down := splitUpDown(string(b))
-    return migrationFile{Name: name, Path: path, UpSQL: up, DownSQL: down}, nil
+    src := strings.ReplaceAll(string(b), "\r\n", "\n")
+    up, down := splitUpDown(src)
+    sum := sha256.Sum256([]byte(src))
+    return migrationFile{
+        Name:     name,
+        Path:     path,
+        UpSQL:    up,
+        DownSQL:  down,
+        Checksum: hex.EncodeToString(sum[:]),
+        NoTx:     hasNoTxDirective(src),
+    }, nil
 }
 
 func splitUpDown(s string) (up, down string) {
@@ -203,18 +460,31 @@ func splitUpDown(s string) (up, down string) {
     return strings.TrimSpace(upBuf.String()), strings.TrimSpace(downBuf.String())
 }
 
-func migrateUp(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir string) error {
-    files, err := readMigrations(dir)
+func migrateUp(ctx context.Context, cmd *cobra.Command, db *sql.DB, d dialect, opts migrateOptions) error {
+    files, err := readMigrations(opts.Dir)
     if err != nil {
         return err
     }
-    applied, err := getApplied(ctx, db)
+    if err := checkDirty(ctx, cmd, db); err != nil {
+        return err
+    }
+    applied, err := loadApplied(ctx, cmd, db, d, files)
     if err != nil {
         return err
     }
+    if drift := findDrift(files, applied); len(drift) > 0 {
+        fmt.Fprintln(cmd.ErrOrStderr(), "Applied migrations have drifted:")
+        for _, dr := range drift {
+            fmt.Fprintf(cmd.ErrOrStderr(), "  %s\t%s\n", dr.name, dr.status)
+        }
+        if !opts.AllowDrift {
+            return fmt.Errorf("%d applied migrations were modified or deleted since they ran (see migrate:status); restore them or pass --allow-drift", len(drift))
+        }
+        fmt.Fprintln(cmd.ErrOrStderr(), "Continuing despite drift (--allow-drift).")
+    }
     pending := make([]migrationFile, 0)
     for _, f := range files {
-        if !applied[f.Name] {
+        if _, ok := applied[f.Name]; !ok {
             pending = append(pending, f)
         }
     }
@@ -222,36 +492,68 @@ func migrateUp(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir string)
         fmt.Fprintln(cmd.OutOrStdout(), "No pending migrations.")
         return nil
     }
+    for _, m := range pending {
+        if strings.TrimSpace(m.UpSQL) == "" {
+            return fmt.Errorf("migration %s has no up SQL", m.Name)
+        }
+    }
+    mode, err := resolveTxMode(cmd, opts.TxMode, d)
+    if err != nil {
+        return err
+    }
     batch, err := nextBatch(ctx, db)
     if err != nil {
         return err
     }
+    n, err := runGroups(ctx, db, txGroups(pending, mode), migrationStep{
+        inTx: func(tx *sql.Tx, m migrationFile) error { return applyMigration(ctx, tx, d, m, batch) },
+        noTx: func(m migrationFile) error { return applyNoTx(ctx, db, d, m, batch) },
+        done: func(m migrationFile) { fmt.Fprintf(cmd.OutOrStdout(), "Applied %s\n", m.Name) },
+    })
+    if err != nil {
+        reportFailure(cmd, fmt.Sprintf("Migration failed in batch %d", batch), n, len(pending), err)
+        return err
+    }
+    fmt.Fprintf(cmd.OutOrStdout(), "Applied %d migrations in batch %d\n", len(pending), batch)
+    return nil
+}
+
+// applyMigration runs m's up SQL and records it in schema_migrations.
+func applyMigration(ctx context.Context, tx *sql.Tx, d dialect, m migrationFile, batch int) error {
+    if _, err := tx.ExecContext(ctx, m.UpSQL); err != nil {
+        return fmt.Errorf("apply %s: %w", m.Name, err)
+    }
+    if _, err := tx.ExecContext(ctx, d.Rebind(`INSERT INTO schema_migrations(name, batch, checksum) VALUES (?, ?, ?)`), m.Name, batch, m.Checksum); err != nil {
+        return fmt.Errorf("record %s: %w", m.Name, err)
+    }
+    return nil
+}
+
+// rollbackMigration runs m's down SQL and removes its record.
+func rollbackMigration(ctx context.Context, tx *sql.Tx, d dialect, m migrationFile) error {
+    if _, err := tx.ExecContext(ctx, m.DownSQL); err != nil {
+        return fmt.Errorf("rollback %s: %w", m.Name, err)
+    }
+    if _, err := tx.ExecContext(ctx, d.Rebind(`DELETE FROM schema_migrations WHERE name=?`), m.Name); err != nil {
+        return fmt.Errorf("unrecord %s: %w", m.Name, err)
+    }
+    return nil
+}
+
+func inTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
     tx, err := db.BeginTx(ctx, nil)
     if err != nil {
         return err
     }
     defer tx.Rollback()
-    for _, m := range pending {
-        if strings.TrimSpace(m.UpSQL) == "" {
-            return fmt.Errorf("migration %s has no up SQL", m.Name)
-        }
-        if _, err := tx.ExecContext(ctx, m.UpSQL); err != nil {
-            return fmt.Errorf("apply %s: %w", m.Name, err)
-        }
-        if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations(name, batch) VALUES ($1, $2)`, m.Name, batch); err != nil {
-            return err
-        }
-        fmt.Fprintf(cmd.OutOrStdout(), "Applied %s\n", m.Name)
-    }
-    if err := tx.Commit(); err != nil {
+    if err := fn(tx); err != nil {
         return err
     }
-    fmt.Fprintf(cmd.OutOrStdout(), "Applied %d migrations in batch %d\n", len(pending), batch)
-    return nil
+    return tx.Commit()
 }
 
-func migrateRollback(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir string) error {
-    files, err := readMigrations(dir)
+func migrateRollback(ctx context.Context, cmd *cobra.Command, db *sql.DB, d dialect, opts migrateOptions) error {
+    files, err := readMigrations(opts.Dir)
     if err != nil {
         return err
     }
@@ -259,6 +561,9 @@ func migrateRollback(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir st
     for _, f := range files {
         fileMap[f.Name] = f
     }
+    if err := checkDirty(ctx, cmd, db); err != nil {
+        return err
+    }
     last, err := lastBatch(ctx, db)
     if err != nil {
         return err
@@ -268,7 +573,7 @@ func migrateRollback(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir st
         return nil
     }
     // Fetch migrations in last batch in reverse lexicographic order
-    rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations WHERE batch=$1 ORDER BY name DESC`, last)
+    rows, err := db.QueryContext(ctx, d.Rebind(`SELECT name FROM schema_migrations WHERE batch=? ORDER BY name DESC`), last)
     if err != nil {
         return err
     }
@@ -292,31 +597,29 @@ func migrateRollback(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir st
         fmt.Fprintln(cmd.OutOrStdout(), "Nothing to rollback.")
         return nil
     }
-    tx, err := db.BeginTx(ctx, nil)
-    if err != nil {
-        return err
-    }
-    defer tx.Rollback()
     for _, m := range batchFiles {
         if strings.TrimSpace(m.DownSQL) == "" {
             return fmt.Errorf("migration %s has no down SQL", m.Name)
         }
-        if _, err := tx.ExecContext(ctx, m.DownSQL); err != nil {
-            return fmt.Errorf("rollback %s: %w", m.Name, err)
-        }
-        if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE name=$1`, m.Name); err != nil {
-            return err
-        }
-        fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %s\n", m.Name)
     }
-    if err := tx.Commit(); err != nil {
+    mode, err := resolveTxMode(cmd, opts.TxMode, d)
+    if err != nil {
+        return err
+    }
+    n, err := runGroups(ctx, db, txGroups(batchFiles, mode), migrationStep{
+        inTx: func(tx *sql.Tx, m migrationFile) error { return rollbackMigration(ctx, tx, d, m) },
+        noTx: func(m migrationFile) error { return rollbackNoTx(ctx, db, d, m) },
+        done: func(m migrationFile) { fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %s\n", m.Name) },
+    })
+    if err != nil {
+        reportFailure(cmd, fmt.Sprintf("Rollback of batch %d failed", last), n, len(batchFiles), err)
         return err
     }
     fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %d migrations from batch %d\n", len(batchFiles), last)
     return nil
 }
 
-func migrateStatus(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir string) error {
+func migrateStatus(ctx context.Context, cmd *cobra.Command, db *sql.DB, d dialect, dir string) error {
     files, err := readMigrations(dir)
     if err != nil {
         return err
@@ -325,38 +628,126 @@ func migrateStatus(ctx context.Context, cmd *cobra.Command, db *sql.DB, dir stri
     if err != nil {
         return err
     }
-    if len(files) == 0 {
+    drift := findDrift(files, applied)
+    dirty, err := getDirty(ctx, db)
+    if err != nil {
+        return err
+    }
+    if len(files) == 0 && len(drift) == 0 {
         fmt.Fprintln(cmd.OutOrStdout(), "No migration files found.")
         return nil
     }
+    status := make(map[string]string, len(drift))
+    for _, dr := range drift {
+        status[dr.name] = dr.status
+    }
+    for _, name := range dirty {
+        status[name] = "dirty"
+    }
     fmt.Fprintln(cmd.OutOrStdout(), "Name\tStatus")
     for _, f := range files {
         st := "pending"
-        if applied[f.Name] {
+        if _, ok := applied[f.Name]; ok {
             st = "applied"
         }
+        if s, ok := status[f.Name]; ok {
+            st = s
+        }
         fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", f.Name, st)
     }
+    for _, dr := range drift {
+        if dr.status == driftMissing {
+            fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", dr.name, dr.status)
+        }
+    }
     return nil
 }
 
-func getApplied(ctx context.Context, db *sql.DB) (map[string]bool, error) {
-    rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations`)
+// getApplied returns applied migration names mapped to their recorded
+// checksum ("" for rows from before checksums were tracked). It is
+// read-only: a missing table or checksum column (not yet created or
+// upgraded by a migrate run) reads as no migrations or no checksums.
+func getApplied(ctx context.Context, db *sql.DB) (map[string]string, error) {
+    out := make(map[string]string)
+    if !hasMigrationsColumn(ctx, db, "name") {
+        return out, nil
+    }
+    cols := "name, checksum"
+    if !hasMigrationsColumn(ctx, db, "checksum") {
+        cols = "name, NULL"
+    }
+    rows, err := db.QueryContext(ctx, `SELECT `+cols+` FROM schema_migrations`)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
-    out := make(map[string]bool)
     for rows.Next() {
-        var name string
-        if err := rows.Scan(&name); err != nil {
+        var (
+            name string
+            sum  sql.NullString
+        )
+        if err := rows.Scan(&name, &sum); err != nil {
             return nil, err
         }
-        out[name] = true
+        out[name] = sum.String
     }
     return out, rows.Err()
 }
 
+// loadApplied is getApplied plus a one-time backfill: rows recorded before
+// checksums existed adopt the checksum of their current file. It writes, so
+// only migrate calls it, under the migration lock.
+func loadApplied(ctx context.Context, cmd *cobra.Command, db *sql.DB, d dialect, files []migrationFile) (map[string]string, error) {
+    applied, err := getApplied(ctx, db)
+    if err != nil {
+        return nil, err
+    }
+    n := 0
+    for _, f := range files {
+        if sum, ok := applied[f.Name]; !ok || sum != "" {
+            continue
+        }
+        if _, err := db.ExecContext(ctx, d.Rebind(`UPDATE schema_migrations SET checksum=? WHERE name=? AND checksum IS NULL`), f.Checksum, f.Name); err != nil {
+            return nil, fmt.Errorf("backfill checksum for %s: %w", f.Name, err)
+        }
+        applied[f.Name] = f.Checksum
+        n++
+    }
+    if n > 0 {
+        fmt.Fprintf(cmd.ErrOrStderr(), "Recorded checksums for %d previously applied migrations.\n", n)
+    }
+    return applied, nil
+}
+
+const (
+    driftModified = "modified"
+    driftMissing  = "missing file"
+)
+
+type migrationDrift struct {
+    name, status string
+}
+
+// findDrift lists applied migrations whose file changed or disappeared,
+// in name order.
+func findDrift(files []migrationFile, applied map[string]string) []migrationDrift {
+    var out []migrationDrift
+    seen := make(map[string]bool, len(files))
+    for _, f := range files {
+        seen[f.Name] = true
+        if sum, ok := applied[f.Name]; ok && sum != "" && sum != f.Checksum {
+            out = append(out, migrationDrift{f.Name, driftModified})
+        }
+    }
+    for name := range applied {
+        if !seen[name] {
+            out = append(out, migrationDrift{name, driftMissing})
+        }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+    return out
+}
+
 func nextBatch(ctx context.Context, db *sql.DB) (int, error) {
     var max sql.NullInt64
     if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(batch),0) FROM schema_migrations`).Scan(&max); err != nil {
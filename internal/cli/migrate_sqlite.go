@@ -0,0 +1,127 @@
+package cli
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+
+    _ "modernc.org/sqlite"
+)
+
+// sqliteDialect runs migrations on SQLite through the pure-Go
+// modernc.org/sqlite driver, so no cgo or server is needed.
+//
+// DSNs: sqlite://app.db (relative), sqlite:///var/data/app.db (absolute) or
+// a SQLite URI like file:app.db?mode=rwc.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Open(dsn string) (*sql.DB, error) {
+    db, err := sql.Open("sqlite", sqlitePath(dsn))
+    if err != nil {
+        return nil, err
+    }
+    // one writer at a time; avoids SQLITE_BUSY between our own connections
+    db.SetMaxOpenConns(1)
+    return db, nil
+}
+
+func (sqliteDialect) MigrationsTableSQL() string {
+    return `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            name       TEXT PRIMARY KEY,
+            batch      INTEGER NOT NULL,
+            applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            checksum   TEXT,
+            dirty      BOOLEAN NOT NULL DEFAULT FALSE
+        )
+    `
+}
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+func (sqliteDialect) TransactionalDDL() bool { return true }
+
+func (sqliteDialect) BackslashEscapes() bool { return false }
+
+// TryLock claims the single row of a lock table; SQLite has no advisory
+// locks and the connection pool is a single connection.
+func (sqliteDialect) TryLock(ctx context.Context, db *sql.DB, holder string) (func(), bool, error) {
+    if _, err := db.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+            id          INTEGER PRIMARY KEY CHECK (id = 1),
+            holder      TEXT NOT NULL,
+            acquired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )
+    `); err != nil {
+        return nil, false, err
+    }
+    res, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO schema_migrations_lock(id, holder) VALUES (1, ?)`, holder)
+    if err != nil {
+        return nil, false, err
+    }
+    if n, err := res.RowsAffected(); err != nil || n == 0 {
+        return nil, false, err
+    }
+    return func() {
+        _, _ = db.ExecContext(context.Background(), `DELETE FROM schema_migrations_lock WHERE id = 1 AND holder = ?`, holder)
+    }, true, nil
+}
+
+// LockHolder reports the lock row. A crashed run leaves it behind, so the
+// hint says how to clear it.
+func (sqliteDialect) LockHolder(ctx context.Context, db *sql.DB) string {
+    var holder, since string
+    if err := db.QueryRowContext(ctx, `SELECT holder, acquired_at FROM schema_migrations_lock WHERE id = 1`).Scan(&holder, &since); err != nil {
+        return ""
+    }
+    return fmt.Sprintf("%q since %s (if it crashed, run largo migrate:unlock)", holder, since)
+}
+
+// ClearLock deletes the lock row, e.g. after the holder crashed.
+func (sqliteDialect) ClearLock(ctx context.Context, db *sql.DB) (string, error) {
+    var n int
+    if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations_lock'`).Scan(&n); err != nil || n == 0 {
+        return "", err
+    }
+    var holder string
+    err := db.QueryRowContext(ctx, `SELECT holder FROM schema_migrations_lock WHERE id = 1`).Scan(&holder)
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+    if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations_lock WHERE id = 1`); err != nil {
+        return "", err
+    }
+    return holder, nil
+}
+
+func isSQLite(dsn string) bool {
+    l := strings.ToLower(dsn)
+    return strings.HasPrefix(l, "sqlite:") || strings.HasPrefix(l, "sqlite3:") || strings.HasPrefix(l, "file:")
+}
+
+// sqlitePath converts a sqlite:// URL to the driver's DSN and turns on
+// foreign keys and a busy timeout unless the DSN sets pragmas itself.
+func sqlitePath(dsn string) string {
+    p := dsn
+    if !strings.HasPrefix(strings.ToLower(p), "file:") {
+        if i := strings.Index(p, ":"); i != -1 {
+            p = p[i+1:]
+        }
+        p = strings.TrimPrefix(p, "//")
+    }
+    if strings.Contains(p, "_pragma=") {
+        return p
+    }
+    sep := "?"
+    if strings.Contains(p, "?") {
+        sep = "&"
+    }
+    return p + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+
+    "github.com/spf13/cobra"
+)
+
+// migrateFixture returns a migrations dir with one migration and the URL of
+// a SQLite database whose schema_migrations predates checksum and dirty.
+func migrateFixture(t *testing.T) (dir, url string) {
+    t.Helper()
+    root := t.TempDir()
+    dir = filepath.Join(root, "migrations")
+    if err := os.Mkdir(dir, 0o755); err != nil {
+        t.Fatal(err)
+    }
+    for name, src := range map[string]string{
+        "0001_a.sql": "-- up\nCREATE TABLE a (id INTEGER);\n-- down\nDROP TABLE a;\n",
+        "0002_b.sql": "-- up\nCREATE TABLE b (id INTEGER);\n-- down\nDROP TABLE b;\n",
+    } {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    path := filepath.Join(root, "app.db")
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer db.Close()
+    for _, q := range []string{
+        `CREATE TABLE schema_migrations (name TEXT PRIMARY KEY, batch INTEGER NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`,
+        `CREATE TABLE a (id INTEGER)`,
+        `INSERT INTO schema_migrations(name, batch) VALUES ('0001_a.sql', 1)`,
+    } {
+        if _, err := db.Exec(q); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return dir, "sqlite://" + path
+}
+
+func runMigrateCmd(cmd *cobra.Command, args ...string) (string, error) {
+    var out bytes.Buffer
+    cmd.SetOut(&out)
+    cmd.SetErr(&out)
+    cmd.SetArgs(args)
+    err := cmd.Execute()
+    return out.String(), err
+}
+
+func TestMigrateUpgradesOldTableConcurrently(t *testing.T) {
+    dir, url := migrateFixture(t)
+    var wg sync.WaitGroup
+    errs := make([]error, 8)
+    outs := make([]string, 8)
+    for i := range errs {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            outs[i], errs[i] = runMigrateCmd(newMigrateCmd(), "--dir", dir, "--database-url", url)
+        }(i)
+    }
+    wg.Wait()
+    for i, err := range errs {
+        if err != nil {
+            t.Errorf("run %d: %v\n%s", i, err, outs[i])
+        }
+    }
+    out, err := runMigrateCmd(newMigrateStatusCmd(), "--dir", dir, "--database-url", url)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := "Name\tStatus\n0001_a.sql\tapplied\n0002_b.sql\tapplied\n"
+    if out != want {
+        t.Errorf("status = %q, want %q", out, want)
+    }
+}
+
+func TestMigrateStatusIsReadOnly(t *testing.T) {
+    dir, url := migrateFixture(t)
+    db, err := sql.Open("sqlite", url[len("sqlite://"):])
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer db.Close()
+
+    status := func() {
+        t.Helper()
+        out, err := runMigrateCmd(newMigrateStatusCmd(), "--dir", dir, "--database-url", url)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if want := "Name\tStatus\n0001_a.sql\tapplied\n0002_b.sql\tpending\n"; out != want {
+            t.Errorf("status = %q, want %q", out, want)
+        }
+    }
+
+    status()
+    ctx := context.Background()
+    for _, col := range []string{"checksum", "dirty"} {
+        if hasMigrationsColumn(ctx, db, col) {
+            t.Errorf("status added the %s column", col)
+        }
+    }
+
+    if _, err := db.Exec(`ALTER TABLE schema_migrations ADD COLUMN checksum TEXT`); err != nil {
+        t.Fatal(err)
+    }
+    status()
+    var n int
+    if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE checksum IS NOT NULL`).Scan(&n); err != nil {
+        t.Fatal(err)
+    }
+    if n != 0 {
+        t.Errorf("status backfilled %d checksums", n)
+    }
+}
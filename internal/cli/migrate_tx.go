@@ -0,0 +1,307 @@
+package cli
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/spf13/cobra"
+)
+
+// Transaction modes for --tx-mode.
+const (
+    txBatch = "batch" // the whole batch in one transaction (default)
+    txEach  = "each"  // one transaction per migration
+    txNone  = "none"  // no transactions; statements run one by one
+)
+
+// noTxDirective opts a single file out of transactions, e.g. for
+// CREATE INDEX CONCURRENTLY on Postgres.
+const noTxDirective = "-- largo:no-transaction"
+
+// resolveTxMode validates mode and switches dialects whose DDL auto-commits
+// to none: a transaction there would not undo a half-applied file, while
+// running statement by statement marks it dirty. The switch is noted when
+// the user asked for a mode explicitly.
+func resolveTxMode(cmd *cobra.Command, mode string, d dialect) (string, error) {
+    switch mode {
+    case txBatch, txEach, txNone:
+    default:
+        return "", fmt.Errorf("invalid --tx-mode %q (want batch, each or none)", mode)
+    }
+    if mode != txNone && !d.TransactionalDDL() {
+        if cmd.Flags().Changed("tx-mode") {
+            fmt.Fprintf(cmd.ErrOrStderr(), "%s DDL auto-commits; using --tx-mode=none.\n", d.Name())
+        }
+        return txNone, nil
+    }
+    return mode, nil
+}
+
+func hasNoTxDirective(src string) bool {
+    for _, line := range strings.Split(src, "\n") {
+        if strings.EqualFold(strings.TrimSpace(line), noTxDirective) {
+            return true
+        }
+    }
+    return false
+}
+
+// txGroup is a run of migrations executed together: in one transaction, or
+// (noTx) a single migration run statement by statement.
+type txGroup struct {
+    files []migrationFile
+    noTx  bool
+}
+
+// txGroups splits list according to mode. No-transaction files always run
+// alone, so a batch is split around them.
+func txGroups(list []migrationFile, mode string) []txGroup {
+    var out []txGroup
+    for _, m := range list {
+        switch {
+        case mode == txNone || m.NoTx:
+            out = append(out, txGroup{files: []migrationFile{m}, noTx: true})
+        case mode == txBatch && len(out) > 0 && !out[len(out)-1].noTx:
+            last := &out[len(out)-1]
+            last.files = append(last.files, m)
+        default:
+            out = append(out, txGroup{files: []migrationFile{m}})
+        }
+    }
+    return out
+}
+
+// migrationStep runs one migration either inside tx or, without one, against
+// db directly, keeping schema_migrations in sync.
+type migrationStep struct {
+    inTx func(tx *sql.Tx, m migrationFile) error
+    noTx func(m migrationFile) error
+    done func(m migrationFile)
+}
+
+// runGroups executes groups in order and returns how many migrations
+// completed before an error.
+func runGroups(ctx context.Context, db *sql.DB, groups []txGroup, st migrationStep) (int, error) {
+    n := 0
+    for _, g := range groups {
+        if g.noTx {
+            if err := st.noTx(g.files[0]); err != nil {
+                return n, err
+            }
+        } else {
+            err := inTx(ctx, db, func(tx *sql.Tx) error {
+                for _, m := range g.files {
+                    if err := st.inTx(tx, m); err != nil {
+                        return err
+                    }
+                }
+                return nil
+            })
+            if err != nil {
+                return n, err
+            }
+        }
+        for _, m := range g.files {
+            st.done(m)
+        }
+        n += len(g.files)
+    }
+    return n, nil
+}
+
+// dirtyError reports a migration that failed partway without a transaction;
+// its schema_migrations row is left with dirty set.
+type dirtyError struct {
+    name string
+    err  error
+}
+
+func (e *dirtyError) Error() string { return e.err.Error() }
+func (e *dirtyError) Unwrap() error { return e.err }
+
+// applyNoTx records m as dirty, runs its statements one at a time and clears
+// the flag once all succeeded. Everything runs on one connection so session
+// state (SET, temp tables) carries across statements.
+func applyNoTx(ctx context.Context, db *sql.DB, d dialect, m migrationFile, batch int) error {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    if _, err := conn.ExecContext(ctx, d.Rebind(`INSERT INTO schema_migrations(name, batch, checksum, dirty) VALUES (?, ?, ?, ?)`), m.Name, batch, m.Checksum, true); err != nil {
+        return fmt.Errorf("record %s: %w", m.Name, err)
+    }
+    if err := execStatements(ctx, conn, d, m.UpSQL); err != nil {
+        return &dirtyError{m.Name, fmt.Errorf("apply %s: %w", m.Name, err)}
+    }
+    if _, err := conn.ExecContext(ctx, d.Rebind(`UPDATE schema_migrations SET dirty=? WHERE name=?`), false, m.Name); err != nil {
+        return &dirtyError{m.Name, fmt.Errorf("record %s: %w", m.Name, err)}
+    }
+    return nil
+}
+
+// rollbackNoTx marks m dirty, runs its down statements one at a time on one
+// connection and removes its record once all succeeded.
+func rollbackNoTx(ctx context.Context, db *sql.DB, d dialect, m migrationFile) error {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    if _, err := conn.ExecContext(ctx, d.Rebind(`UPDATE schema_migrations SET dirty=? WHERE name=?`), true, m.Name); err != nil {
+        return fmt.Errorf("mark %s: %w", m.Name, err)
+    }
+    if err := execStatements(ctx, conn, d, m.DownSQL); err != nil {
+        return &dirtyError{m.Name, fmt.Errorf("rollback %s: %w", m.Name, err)}
+    }
+    if _, err := conn.ExecContext(ctx, d.Rebind(`DELETE FROM schema_migrations WHERE name=?`), m.Name); err != nil {
+        return &dirtyError{m.Name, fmt.Errorf("unrecord %s: %w", m.Name, err)}
+    }
+    return nil
+}
+
+// checkDirty refuses to continue while a migration is marked dirty, since
+// the schema is in an unknown state until someone repairs it.
+func checkDirty(ctx context.Context, cmd *cobra.Command, db *sql.DB) error {
+    names, err := getDirty(ctx, db)
+    if err != nil {
+        return err
+    }
+    if len(names) == 0 {
+        return nil
+    }
+    fmt.Fprintln(cmd.ErrOrStderr(), "Migrations left dirty by a failed non-transactional run:")
+    for _, name := range names {
+        fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n", name)
+    }
+    return fmt.Errorf("%d migrations are dirty; repair the schema, then delete their rows to re-run them or set dirty to false to keep them", len(names))
+}
+
+// getDirty lists migrations whose dirty flag is set, in name order. A table
+// without the dirty column has none.
+func getDirty(ctx context.Context, db *sql.DB) ([]string, error) {
+    if !hasMigrationsColumn(ctx, db, "dirty") {
+        return nil, nil
+    }
+    rows, err := db.QueryContext(ctx, `SELECT name FROM schema_migrations WHERE dirty ORDER BY name`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []string
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        out = append(out, name)
+    }
+    return out, rows.Err()
+}
+
+// execStatements runs each statement on its own, so none is wrapped in an
+// implicit multi-statement transaction.
+func execStatements(ctx context.Context, conn *sql.Conn, d dialect, src string) error {
+    stmts := splitStatements(src, d.BackslashEscapes())
+    for i, s := range stmts {
+        if _, err := conn.ExecContext(ctx, s); err != nil {
+            return fmt.Errorf("statement %d of %d (%d ran): %w", i+1, len(stmts), i, err)
+        }
+    }
+    return nil
+}
+
+// reportFailure explains what state a failed run left behind.
+func reportFailure(cmd *cobra.Command, what string, done, total int, err error) {
+    w := cmd.ErrOrStderr()
+    fmt.Fprintf(w, "%s: %d of %d completed.\n", what, done, total)
+    var de *dirtyError
+    if errors.As(err, &de) {
+        fmt.Fprintf(w, "%s ran without a transaction and stopped partway, so it is marked dirty in schema_migrations.\n", de.name)
+        fmt.Fprintln(w, "Repair the schema by hand, then delete its row to run it again or set dirty to false to keep it.")
+    }
+}
+
+// splitStatements splits SQL on semicolons outside quotes, comments and
+// Postgres dollar-quoted bodies, dropping statements that are only comments.
+// Quotes are escaped by doubling them; backslash escapes them too only when
+// backslash is set (MySQL), as standard SQL treats it as a plain character.
+func splitStatements(src string, backslash bool) []string {
+    var out []string
+    start, code := 0, false
+    for i := 0; i < len(src); {
+        c := src[i]
+        switch {
+        case c == '\'' || c == '"' || c == '`':
+            j := i + 1
+            for j < len(src) {
+                if backslash && src[j] == '\\' && c != '`' {
+                    j += 2
+                    continue
+                }
+                if src[j] == c {
+                    if j+1 < len(src) && src[j+1] == c {
+                        j += 2
+                        continue
+                    }
+                    break
+                }
+                j++
+            }
+            i, code = j+1, true
+        case c == '-' && strings.HasPrefix(src[i:], "--"):
+            if nl := strings.IndexByte(src[i:], '\n'); nl >= 0 {
+                i += nl + 1
+            } else {
+                i = len(src)
+            }
+        case c == '/' && strings.HasPrefix(src[i:], "/*"):
+            if end := strings.Index(src[i+2:], "*/"); end >= 0 {
+                i += end + 4
+            } else {
+                i = len(src)
+            }
+        case c == '$' && dollarTag(src[i:]) != "":
+            tag := dollarTag(src[i:])
+            if end := strings.Index(src[i+len(tag):], tag); end >= 0 {
+                i += len(tag) + end + len(tag)
+            } else {
+                i = len(src)
+            }
+            code = true
+        case c == ';':
+            if code {
+                out = append(out, strings.TrimSpace(src[start:i]))
+            }
+            i++
+            start, code = i, false
+        default:
+            if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+                code = true
+            }
+            i++
+        }
+    }
+    if code {
+        out = append(out, strings.TrimSpace(src[start:]))
+    }
+    return out
+}
+
+// dollarTag returns the $tag$ opening s, or "".
+func dollarTag(s string) string {
+    for j := 1; j < len(s); j++ {
+        c := s[j]
+        switch {
+        case c == '$':
+            return s[:j+1]
+        case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > 1 && c >= '0' && c <= '9':
+        default:
+            return ""
+        }
+    }
+    return ""
+}
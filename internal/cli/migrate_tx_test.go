@@ -0,0 +1,200 @@
+package cli
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+    tests := []struct {
+        name      string
+        src       string
+        backslash bool
+        want      []string
+    }{
+        {
+            name: "simple",
+            src:  "CREATE TABLE a (id int);\nCREATE TABLE b (id int);",
+            want: []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+        },
+        {
+            name: "no trailing semicolon",
+            src:  "SELECT 1;\nSELECT 2",
+            want: []string{"SELECT 1", "SELECT 2"},
+        },
+        {
+            name: "semicolon in single quotes",
+            src:  "INSERT INTO t VALUES ('a;b');SELECT 1;",
+            want: []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"},
+        },
+        {
+            name: "doubled single quote",
+            src:  "INSERT INTO t VALUES ('it''s; fine');SELECT 1;",
+            want: []string{"INSERT INTO t VALUES ('it''s; fine')", "SELECT 1"},
+        },
+        {
+            name: "doubled double quote",
+            src:  `CREATE TABLE "a""; b" (id int);SELECT 1;`,
+            want: []string{`CREATE TABLE "a""; b" (id int)`, "SELECT 1"},
+        },
+        {
+            name: "backslash is literal in standard SQL",
+            src:  `INSERT INTO t VALUES ('C:\'); DROP TABLE t;`,
+            want: []string{`INSERT INTO t VALUES ('C:\')`, "DROP TABLE t"},
+        },
+        {
+            name:      "backslash escapes quote on MySQL",
+            src:       `INSERT INTO t VALUES ('it\'s; fine'); SELECT 1;`,
+            backslash: true,
+            want:      []string{`INSERT INTO t VALUES ('it\'s; fine')`, "SELECT 1"},
+        },
+        {
+            name:      "backtick ignores backslash",
+            src:       "CREATE TABLE `a\\` (id int); SELECT 1;",
+            backslash: true,
+            want:      []string{"CREATE TABLE `a\\` (id int)", "SELECT 1"},
+        },
+        {
+            name: "dollar quoting",
+            src:  "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;SELECT 1;",
+            want: []string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT 1"},
+        },
+        {
+            name: "tagged dollar quoting",
+            src:  "DO $body$ BEGIN PERFORM 'x;$$'; END $body$;SELECT 1;",
+            want: []string{"DO $body$ BEGIN PERFORM 'x;$$'; END $body$", "SELECT 1"},
+        },
+        {
+            name: "positional parameter is not a dollar quote",
+            src:  "PREPARE p AS SELECT $1;SELECT 2;",
+            want: []string{"PREPARE p AS SELECT $1", "SELECT 2"},
+        },
+        {
+            name: "line comment",
+            src:  "SELECT 1; -- not; a statement\nSELECT 2;",
+            want: []string{"SELECT 1", "-- not; a statement\nSELECT 2"},
+        },
+        {
+            name: "block comment",
+            src:  "SELECT /* a; b */ 1;SELECT 2;",
+            want: []string{"SELECT /* a; b */ 1", "SELECT 2"},
+        },
+        {
+            name: "comment-only statements dropped",
+            src:  "-- largo:no-transaction\n/* nothing */;\nCREATE INDEX CONCURRENTLY i ON t (a);\n-- trailing",
+            want: []string{"CREATE INDEX CONCURRENTLY i ON t (a)"},
+        },
+        {
+            name: "empty",
+            src:  "  \n-- only a comment\n",
+            want: nil,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := splitStatements(tt.src, tt.backslash)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("splitStatements(%q) = %q, want %q", tt.src, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestHasNoTxDirective(t *testing.T) {
+    tests := []struct {
+        src  string
+        want bool
+    }{
+        {"-- largo:no-transaction\n-- up\nCREATE INDEX CONCURRENTLY i ON t (a);", true},
+        {"-- up\n  -- LARGO:NO-TRANSACTION  \nSELECT 1;", true},
+        {"-- up\nSELECT 1; -- largo:no-transaction", false},
+        {"-- up\n-- (add a \"-- largo:no-transaction\" line to opt out)", false},
+        {"-- up\nSELECT 1;", false},
+    }
+    for _, tt := range tests {
+        if got := hasNoTxDirective(tt.src); got != tt.want {
+            t.Errorf("hasNoTxDirective(%q) = %v, want %v", tt.src, got, tt.want)
+        }
+    }
+}
+
+func TestParseMigrationFileNoTx(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "0001_index.sql")
+    src := "-- largo:no-transaction\r\n-- up\r\nCREATE INDEX CONCURRENTLY i ON t (a);\r\n-- down\r\nDROP INDEX CONCURRENTLY i;\r\n"
+    if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    m, err := parseMigrationFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !m.NoTx {
+        t.Error("NoTx = false, want true")
+    }
+    want := []string{"-- largo:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (a)"}
+    if got := splitStatements(m.UpSQL, false); !reflect.DeepEqual(got, want) {
+        t.Errorf("up statements = %q", got)
+    }
+}
+
+func TestTxGroups(t *testing.T) {
+    a, b := migrationFile{Name: "a"}, migrationFile{Name: "b"}
+    nt := migrationFile{Name: "nt", NoTx: true}
+    c := migrationFile{Name: "c"}
+    names := func(gs []txGroup) [][]string {
+        var out [][]string
+        for _, g := range gs {
+            var ns []string
+            for _, f := range g.files {
+                ns = append(ns, f.Name)
+            }
+            if g.noTx {
+                ns = append(ns, "(no tx)")
+            }
+            out = append(out, ns)
+        }
+        return out
+    }
+    tests := []struct {
+        mode string
+        want [][]string
+    }{
+        {txBatch, [][]string{{"a", "b"}, {"nt", "(no tx)"}, {"c"}}},
+        {txEach, [][]string{{"a"}, {"b"}, {"nt", "(no tx)"}, {"c"}}},
+        {txNone, [][]string{{"a", "(no tx)"}, {"b", "(no tx)"}, {"nt", "(no tx)"}, {"c", "(no tx)"}}},
+    }
+    for _, tt := range tests {
+        if got := names(txGroups([]migrationFile{a, b, nt, c}, tt.mode)); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("txGroups(%s) = %v, want %v", tt.mode, got, tt.want)
+        }
+    }
+}
+
+func TestResolveTxMode(t *testing.T) {
+    tests := []struct {
+        d    dialect
+        mode string
+        want string
+    }{
+        {postgresDialect{}, txBatch, txBatch},
+        {sqliteDialect{}, txEach, txEach},
+        {mysqlDialect{}, txBatch, txNone},
+        {mysqlDialect{}, txEach, txNone},
+        {mysqlDialect{}, txNone, txNone},
+    }
+    for _, tt := range tests {
+        got, err := resolveTxMode(newMigrateCmd(), tt.mode, tt.d)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != tt.want {
+            t.Errorf("resolveTxMode(%s, %s) = %s, want %s", tt.d.Name(), tt.mode, got, tt.want)
+        }
+    }
+    if _, err := resolveTxMode(newMigrateCmd(), "all", sqliteDialect{}); err == nil {
+        t.Error("resolveTxMode accepted an unknown mode")
+    }
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+    "bytes"
     "errors"
     "fmt"
     "io/fs"
@@ -16,11 +17,12 @@ import (
 
 type newOptions struct {
     Module string
+    DB     string
     Force  bool
 }
 
 func newNewCmd() *cobra.Command {
-    opts := newOptions{}
+    opts := newOptions{DB: "postgres"}
     cmd := &cobra.Command{
         Use:   "new <app>",
         Short: "Scaffold a new LarGo application",
@@ -34,8 +36,13 @@ func newNewCmd() *cobra.Command {
             if module == "" {
                 module = appName
             }
+            switch opts.DB {
+            case "postgres", "mysql", "sqlite":
+            default:
+                return fmt.Errorf("invalid --db %q (want postgres, mysql or sqlite)", opts.DB)
+            }
             targetDir := appName
-            if err := scaffoldApp(cmd, targetDir, appName, module, opts.Force); err != nil {
+            if err := scaffoldApp(cmd, targetDir, appName, module, opts.DB, opts.Force); err != nil {
                 return err
             }
             fmt.Fprintf(cmd.OutOrStdout(), "\nCreated %s. Next steps:\n", appName)
@@ -44,6 +51,7 @@ func newNewCmd() *cobra.Command {
         },
     }
     cmd.Flags().StringVarP(&opts.Module, "module", "m", "", "Go module path for the new app (default: app name)")
+    cmd.Flags().StringVar(&opts.DB, "db", opts.DB, "Database the starter migrations and .env target: postgres, mysql or sqlite")
     cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Overwrite existing files if present")
     return cmd
 }
@@ -63,7 +71,10 @@ func sanitizeAppName(s string) string {
     return strings.Trim(string(out), ".-_")
 }
 
-func scaffoldApp(cmd *cobra.Command, targetDir, appName, modulePath string, force bool) error {
+// scaffoldApp renders the app templates into targetDir. Templates that
+// render to nothing (e.g. Postgres-only migrations for another db) are
+// skipped.
+func scaffoldApp(cmd *cobra.Command, targetDir, appName, modulePath, db string, force bool) error {
     // If target exists and not empty, guard unless --force
     if fi, err := os.Stat(targetDir); err == nil && fi.IsDir() && !force {
         // Check if directory is empty
@@ -80,6 +91,7 @@ func scaffoldApp(cmd *cobra.Command, targetDir, appName, modulePath string, forc
     data := map[string]any{
         "AppName":    appName,
         "ModulePath": modulePath,
+        "DB":         db,
         "Year":       time.Now().Year(),
     }
 
@@ -111,6 +123,13 @@ func scaffoldApp(cmd *cobra.Command, targetDir, appName, modulePath string, forc
         if err != nil {
             return fmt.Errorf("parse template %s: %w", path, err)
         }
+        var out bytes.Buffer
+        if err := t.Execute(&out, data); err != nil {
+            return fmt.Errorf("render %s: %w", path, err)
+        }
+        if strings.TrimSpace(out.String()) == "" {
+            return nil
+        }
         // Guard if exists and not forcing
         if !force {
             if _, err := os.Stat(dest); err == nil {
@@ -120,15 +139,7 @@ func scaffoldApp(cmd *cobra.Command, targetDir, appName, modulePath string, forc
         if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
             return err
         }
-        f, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
-        if err != nil {
-            return err
-        }
-        defer f.Close()
-        if err := t.Execute(f, data); err != nil {
-            return fmt.Errorf("render %s: %w", path, err)
-        }
-        return nil
+        return os.WriteFile(dest, out.Bytes(), 0o644)
     })
 }
 
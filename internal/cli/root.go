@@ -30,6 +30,8 @@ func newRootCmd(version, commit, date string) *cobra.Command {
         newVersionCmd(version, commit, date),
         newNewCmd(),
         newServeCmd(),
+        newRouteListCmd(),
+        newCertDevCmd(),
         newMakeControllerCmd(),
         newMakeMigrationCmd(),
         newMakeModelCmd(),
@@ -37,6 +39,7 @@ func newRootCmd(version, commit, date string) *cobra.Command {
         newMigrateCmd(),
         newMigrateRollbackCmd(),
         newMigrateStatusCmd(),
+        newMigrateUnlockCmd(),
     )
 
     return cmd
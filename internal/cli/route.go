@@ -0,0 +1,133 @@
+package cli
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "text/tabwriter"
+    "time"
+
+    "github.com/spf13/cobra"
+    "github.com/MohammedMogeab/largo/pkg/httpx"
+)
+
+type routeListOptions struct {
+    Method  string
+    Path    string
+    JSON    bool
+    Timeout time.Duration
+}
+
+func newRouteListCmd() *cobra.Command {
+    opts := routeListOptions{Timeout: 2 * time.Minute}
+    cmd := &cobra.Command{
+        Use:   "route:list [target]",
+        Short: "List the routes registered by the app",
+        Long:  "Build and run the app in route-dump mode (" + httpx.RouteDumpEnv + ") and print its routes. The app must serve through httpx.Serve/ServeConfig. Defaults to ./cmd/server.",
+        Args:  cobra.MaximumNArgs(1),
+        RunE: func(cmd *cobra.Command, args []string) error {
+            return runRouteList(cmd, resolveTarget(args), opts)
+        },
+        Example: "  largo route:list\n  largo route:list --method GET --path /admin\n  largo route:list ./cmd/admin --json",
+    }
+    cmd.Flags().StringVar(&opts.Method, "method", "", "Only show routes for this HTTP method")
+    cmd.Flags().StringVar(&opts.Path, "path", "", "Only show routes whose pattern starts with this prefix")
+    cmd.Flags().BoolVar(&opts.JSON, "json", false, "Print routes as JSON")
+    cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "Give up if building and running the app takes longer than this")
+    return cmd
+}
+
+func runRouteList(cmd *cobra.Command, target string, opts routeListOptions) error {
+    if _, err := exec.LookPath("go"); err != nil {
+        return errors.New("'go' tool not found in PATH; install Go or update PATH")
+    }
+    tmp, err := os.MkdirTemp("", "largo-routes-")
+    if err != nil {
+        return err
+    }
+    defer os.RemoveAll(tmp)
+    dump := filepath.Join(tmp, "routes.json")
+    bin := filepath.Join(tmp, "app")
+    if runtime.GOOS == "windows" {
+        bin += ".exe"
+    }
+
+    ctx, cancel := context.WithTimeout(cmd.Context(), opts.Timeout)
+    defer cancel()
+    // Build first and run the binary directly, so the timeout kills the app
+    // itself rather than only the go tool.
+    if err := runQuiet(ctx, cmd, exec.CommandContext(ctx, "go", "build", "-o", bin, target)); err != nil {
+        return fmt.Errorf("build %s: %w", target, err)
+    }
+    c := exec.CommandContext(ctx, bin)
+    c.Env = upsertEnv(os.Environ(), httpx.RouteDumpEnv, dump)
+    if err := runQuiet(ctx, cmd, c); err != nil {
+        if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+            return fmt.Errorf("%s did not exit within %s; does it block before calling httpx.Serve?", target, opts.Timeout)
+        }
+        return fmt.Errorf("run %s in route-dump mode: %w", target, err)
+    }
+    b, err := os.ReadFile(dump)
+    if err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return fmt.Errorf("%s exited without dumping routes; does it serve via httpx.Serve or httpx.ServeConfig?", target)
+        }
+        return err
+    }
+    var routes []httpx.RouteInfo
+    if err := json.Unmarshal(b, &routes); err != nil {
+        return fmt.Errorf("decode routes: %w", err)
+    }
+
+    filtered := routes[:0]
+    for _, rt := range routes {
+        if opts.Method != "" && !strings.EqualFold(rt.Method, opts.Method) {
+            continue
+        }
+        if opts.Path != "" && !strings.HasPrefix(rt.Pattern, opts.Path) {
+            continue
+        }
+        filtered = append(filtered, rt)
+    }
+
+    out := cmd.OutOrStdout()
+    if opts.JSON {
+        enc := json.NewEncoder(out)
+        enc.SetIndent("", "  ")
+        return enc.Encode(filtered)
+    }
+    if len(filtered) == 0 {
+        fmt.Fprintln(out, "No routes found.")
+        return nil
+    }
+    tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tMIDDLEWARE")
+    for _, rt := range filtered {
+        fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rt.Method, rt.Pattern, valueOr(rt.Name, "-"), valueOr(strings.Join(rt.Middleware, ", "), "-"))
+    }
+    return tw.Flush()
+}
+
+// runQuiet runs c with its output buffered, printing it to stderr only when
+// c fails.
+func runQuiet(ctx context.Context, cmd *cobra.Command, c *exec.Cmd) error {
+    var out bytes.Buffer
+    c.Stdout = &out
+    c.Stderr = &out
+    err := c.Run()
+    if err == nil {
+        return nil
+    }
+    cmd.ErrOrStderr().Write(out.Bytes())
+    if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+        return fmt.Errorf("timed out: %w", err)
+    }
+    return err
+}
@@ -29,16 +29,7 @@ func newServeCmd() *cobra.Command {
         Long:  "Run the generated application's server using 'go run'. Defaults to ./cmd/server. Example: 'largo serve server'",
         Args:  cobra.MaximumNArgs(1),
         RunE: func(cmd *cobra.Command, args []string) error {
-            target := "./cmd/server"
-            if len(args) == 1 {
-                // If user gives a bare name like "server", map to ./cmd/server
-                if !strings.Contains(args[0], "/") && !strings.HasPrefix(args[0], ".") {
-                    target = filepath.Join("./cmd", args[0])
-                } else {
-                    target = args[0]
-                }
-            }
-            return runServe(cmd, target, opts)
+            return runServe(cmd, resolveTarget(args), opts)
         },
         Example: "  largo serve\n  largo serve server -p 8080 --env dev\n  largo serve ./cmd/admin",
     }
@@ -95,6 +86,18 @@ func runServe(cmd *cobra.Command, target string, opts serveOptions) error {
     return nil
 }
 
+// resolveTarget maps the optional [target] argument to a go run package path.
+func resolveTarget(args []string) string {
+    if len(args) == 0 {
+        return "./cmd/server"
+    }
+    // If user gives a bare name like "server", map to ./cmd/server
+    if !strings.Contains(args[0], "/") && !strings.HasPrefix(args[0], ".") {
+        return "./" + filepath.Join("cmd", args[0])
+    }
+    return args[0]
+}
+
 func upsertEnv(env []string, key, value string) []string {
     prefix := key + "="
     for i, kv := range env {
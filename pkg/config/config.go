@@ -4,6 +4,7 @@ import (
     "log/slog"
     "os"
     "strconv"
+    "strings"
     "time"
 
     "github.com/joho/godotenv"
@@ -13,6 +14,9 @@ type Config struct {
     App  AppConfig
     DB   DBConfig
     HTTP HTTPConfig
+    TLS  TLSConfig
+    CORS CORSConfig
+    Log  LogConfig
 }
 
 type AppConfig struct {
@@ -25,10 +29,34 @@ type DBConfig struct {
 }
 
 type HTTPConfig struct {
-    ReadTimeoutSec  int
-    WriteTimeoutSec int
-    IdleTimeoutSec  int
-    MaxHeaderBytes  int
+    ReadTimeoutSec     int
+    WriteTimeoutSec    int
+    IdleTimeoutSec     int
+    MaxHeaderBytes     int
+    ShutdownTimeoutSec int
+    ShutdownDelaySec   int  // keep serving (readiness failing) this long before draining
+    H2C                bool // serve plaintext HTTP/2 (h2c) when TLS is off
+    MaxBodyBytes       int64
+    HandlerTimeoutSec  int
+    TrustedProxies     []string // CIDRs/IPs whose forwarding header is honoured
+    RealIPHeader       string   // the one header trusted for the client IP
+}
+
+// TLSConfig enables HTTPS when both CertFile and KeyFile are set.
+type TLSConfig struct {
+    CertFile     string
+    KeyFile      string
+    MinVersion   string // "1.2" or "1.3"
+    ClientCAFile string // when set, clients must present a cert signed by this CA (mTLS)
+    RedirectPort int    // when > 0, a plaintext listener redirects to HTTPS
+}
+
+// LogConfig configures the logger built by pkg/logx.
+type LogConfig struct {
+    Level  string // debug|info|warn|error
+    Format string // text|json
+    Source bool   // include file:line
+    File   string // stdout, stderr or a file path
 }
 
 // Load reads .env (if present), then environment variables, then applies defaults.
@@ -48,6 +76,34 @@ func Load() *Config {
     cfg.HTTP.WriteTimeoutSec = atoiDefault("HTTP_WRITE_TIMEOUT", 10)
     cfg.HTTP.IdleTimeoutSec = atoiDefault("HTTP_IDLE_TIMEOUT", 60)
     cfg.HTTP.MaxHeaderBytes = atoiDefault("HTTP_MAX_HEADER_BYTES", 1<<20) // 1MB
+    cfg.HTTP.ShutdownTimeoutSec = atoiDefault("HTTP_SHUTDOWN_TIMEOUT", 15)
+    cfg.HTTP.ShutdownDelaySec = atoiDefault("HTTP_SHUTDOWN_DELAY", 0)
+    cfg.HTTP.H2C = boolDefault("HTTP_H2C", false)
+    cfg.HTTP.MaxBodyBytes = int64(atoiDefault("HTTP_MAX_BODY_BYTES", 10<<20)) // 10MB
+    cfg.HTTP.HandlerTimeoutSec = atoiDefault("HTTP_HANDLER_TIMEOUT", 0)
+    cfg.HTTP.TrustedProxies = listDefault("TRUSTED_PROXIES", nil)
+    cfg.HTTP.RealIPHeader = getenvDefault("REAL_IP_HEADER", "X-Forwarded-For")
+
+    // TLS
+    cfg.TLS.CertFile = os.Getenv("TLS_CERT_FILE")
+    cfg.TLS.KeyFile = os.Getenv("TLS_KEY_FILE")
+    cfg.TLS.MinVersion = getenvDefault("TLS_MIN_VERSION", "1.2")
+    cfg.TLS.ClientCAFile = os.Getenv("TLS_CLIENT_CA_FILE")
+    cfg.TLS.RedirectPort = atoiDefault("TLS_REDIRECT_PORT", 0)
+
+    // CORS
+    cfg.CORS.AllowedOrigins = listDefault("CORS_ALLOWED_ORIGINS", nil)
+    cfg.CORS.AllowedMethods = listDefault("CORS_ALLOWED_METHODS", nil)
+    cfg.CORS.AllowedHeaders = listDefault("CORS_ALLOWED_HEADERS", nil)
+    cfg.CORS.ExposedHeaders = listDefault("CORS_EXPOSED_HEADERS", nil)
+    cfg.CORS.AllowCredentials = boolDefault("CORS_ALLOW_CREDENTIALS", false)
+    cfg.CORS.MaxAgeSec = atoiDefault("CORS_MAX_AGE", 600)
+
+    // Logging
+    cfg.Log.Level = getenvDefault("LOG_LEVEL", "info")
+    cfg.Log.Format = getenvDefault("LOG_FORMAT", "text")
+    cfg.Log.Source = boolDefault("LOG_SOURCE", false)
+    cfg.Log.File = getenvDefault("LOG_FILE", "stdout")
 
     // Log effective config (concise)
     slog.Info("config.loaded",
@@ -57,6 +113,17 @@ func Load() *Config {
         slog.Int("http_write_timeout_sec", cfg.HTTP.WriteTimeoutSec),
         slog.Int("http_idle_timeout_sec", cfg.HTTP.IdleTimeoutSec),
         slog.Int("http_max_header_bytes", cfg.HTTP.MaxHeaderBytes),
+        slog.Int("http_shutdown_timeout_sec", cfg.HTTP.ShutdownTimeoutSec),
+        slog.Int("http_shutdown_delay_sec", cfg.HTTP.ShutdownDelaySec),
+        slog.Bool("http_h2c", cfg.HTTP.H2C),
+        slog.Int64("http_max_body_bytes", cfg.HTTP.MaxBodyBytes),
+        slog.Int("http_handler_timeout_sec", cfg.HTTP.HandlerTimeoutSec),
+        slog.Int("trusted_proxies", len(cfg.HTTP.TrustedProxies)),
+        slog.String("real_ip_header", cfg.HTTP.RealIPHeader),
+        slog.Bool("tls", cfg.TLS.Enabled()),
+        slog.Int("cors_origins", len(cfg.CORS.AllowedOrigins)),
+        slog.String("log_level", cfg.Log.Level),
+        slog.String("log_format", cfg.Log.Format),
     )
     if cfg.DB.URL == "" {
         slog.Warn("config.database_url_missing", slog.String("hint", "DATABASE_URL required for migrations"))
@@ -64,9 +131,31 @@ func Load() *Config {
     return cfg
 }
 
+// CORSConfig holds CORS_* settings; CORS is off when AllowedOrigins is empty.
+type CORSConfig struct {
+    AllowedOrigins   []string
+    AllowedMethods   []string
+    AllowedHeaders   []string
+    ExposedHeaders   []string
+    AllowCredentials bool
+    MaxAgeSec        int
+}
+
 func (h HTTPConfig) ReadTimeout() time.Duration  { return time.Duration(h.ReadTimeoutSec) * time.Second }
 func (h HTTPConfig) WriteTimeout() time.Duration { return time.Duration(h.WriteTimeoutSec) * time.Second }
 func (h HTTPConfig) IdleTimeout() time.Duration  { return time.Duration(h.IdleTimeoutSec) * time.Second }
+func (h HTTPConfig) ShutdownTimeout() time.Duration {
+    return time.Duration(h.ShutdownTimeoutSec) * time.Second
+}
+func (h HTTPConfig) ShutdownDelay() time.Duration {
+    return time.Duration(h.ShutdownDelaySec) * time.Second
+}
+func (h HTTPConfig) HandlerTimeout() time.Duration {
+    return time.Duration(h.HandlerTimeoutSec) * time.Second
+}
+
+// Enabled reports whether a certificate and key are configured.
+func (t TLSConfig) Enabled() bool { return t.CertFile != "" && t.KeyFile != "" }
 
 func getenvDefault(key, def string) string {
     if v := os.Getenv(key); v != "" {
@@ -84,3 +173,26 @@ func atoiDefault(key string, def int) int {
     return def
 }
 
+func boolDefault(key string, def bool) bool {
+    if v := os.Getenv(key); v != "" {
+        if b, err := strconv.ParseBool(v); err == nil {
+            return b
+        }
+    }
+    return def
+}
+
+// listDefault splits a comma-separated value, trimming blanks.
+func listDefault(key string, def []string) []string {
+    v := os.Getenv(key)
+    if v == "" {
+        return def
+    }
+    var out []string
+    for _, p := range strings.Split(v, ",") {
+        if p = strings.TrimSpace(p); p != "" {
+            out = append(out, p)
+        }
+    }
+    return out
+}
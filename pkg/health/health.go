@@ -0,0 +1,169 @@
+package health
+
+import (
+    "context"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx"
+)
+
+// DefaultTimeout bounds a single check unless overridden with Timeout.
+const DefaultTimeout = 2 * time.Second
+
+// CheckFunc reports an unhealthy dependency by returning an error. It should
+// honor ctx, which carries the check's timeout. (*sql.DB).PingContext and
+// (*pgxpool.Pool).Ping fit as-is.
+type CheckFunc func(ctx context.Context) error
+
+// Option configures a check.
+type Option func(*check)
+
+// Timeout overrides DefaultTimeout for a check.
+func Timeout(d time.Duration) Option { return func(c *check) { c.timeout = d } }
+
+type check struct {
+    name    string
+    fn      CheckFunc
+    timeout time.Duration
+}
+
+// Registry holds named liveness and readiness checks.
+type Registry struct {
+    mu    sync.RWMutex
+    live  []check
+    ready []check
+}
+
+// New returns an empty Registry. With no checks, /livez and /readyz report ok
+// (readiness still fails while the server shuts down).
+func New() *Registry { return &Registry{} }
+
+// AddLiveness registers a check served by /livez. Keep these cheap and
+// local: a failing liveness probe restarts the process.
+func (r *Registry) AddLiveness(name string, fn CheckFunc, opts ...Option) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.live = append(r.live, newCheck(name, fn, opts))
+}
+
+// AddReadiness registers a check served by /readyz, typically external
+// dependencies such as the database.
+func (r *Registry) AddReadiness(name string, fn CheckFunc, opts ...Option) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.ready = append(r.ready, newCheck(name, fn, opts))
+}
+
+func newCheck(name string, fn CheckFunc, opts []Option) check {
+    c := check{name: name, fn: fn, timeout: DefaultTimeout}
+    for _, o := range opts {
+        o(&c)
+    }
+    return c
+}
+
+// Mount registers GET /livez and GET /readyz (named "health.live" and
+// "health.ready") on router.
+func (r *Registry) Mount(router *httpx.Router) {
+    router.GET("/livez", r.LiveHandler()).Name("health.live")
+    router.GET("/readyz", r.ReadyHandler()).Name("health.ready")
+}
+
+// LiveHandler runs the liveness checks.
+func (r *Registry) LiveHandler() httpx.HandlerFunc {
+    return func(c *httpx.Context) {
+        r.mu.RLock()
+        checks := r.live
+        r.mu.RUnlock()
+        write(c, run(c.R.Context(), checks), "")
+    }
+}
+
+// ReadyHandler runs the readiness checks; it fails without running them once
+// a graceful shutdown has begun (see httpx.ShuttingDown).
+func (r *Registry) ReadyHandler() httpx.HandlerFunc {
+    return func(c *httpx.Context) {
+        if httpx.ShuttingDown() {
+            write(c, nil, StatusShuttingDown)
+            return
+        }
+        r.mu.RLock()
+        checks := r.ready
+        r.mu.RUnlock()
+        write(c, run(c.R.Context(), checks), "")
+    }
+}
+
+// Status values used in responses.
+const (
+    StatusOK           = "ok"
+    StatusFail         = "fail"
+    StatusShuttingDown = "shutting_down"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+    Status     string  `json:"status"`
+    DurationMS float64 `json:"duration_ms"`
+    Error      string  `json:"error,omitempty"`
+}
+
+// Report is the JSON body returned by /livez and /readyz.
+type Report struct {
+    Status string            `json:"status"`
+    Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// run executes checks concurrently, each under its own timeout.
+func run(ctx context.Context, checks []check) map[string]Result {
+    out := make(map[string]Result, len(checks))
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for _, ch := range checks {
+        wg.Add(1)
+        go func(ch check) {
+            defer wg.Done()
+            cctx, cancel := context.WithTimeout(ctx, ch.timeout)
+            defer cancel()
+            start := time.Now()
+            done := make(chan error, 1)
+            go func() { done <- ch.fn(cctx) }()
+            var err error
+            select {
+            case err = <-done:
+            case <-cctx.Done():
+                // don't let a check that ignores ctx hold the probe
+                err = cctx.Err()
+            }
+            res := Result{Status: StatusOK, DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+            if err != nil {
+                res.Status, res.Error = StatusFail, err.Error()
+            }
+            mu.Lock()
+            out[ch.name] = res
+            mu.Unlock()
+        }(ch)
+    }
+    wg.Wait()
+    return out
+}
+
+func write(c *httpx.Context, results map[string]Result, status string) {
+    rep := Report{Status: StatusOK, Checks: results}
+    for _, res := range results {
+        if res.Status != StatusOK {
+            rep.Status = StatusFail
+        }
+    }
+    if status != "" {
+        rep.Status = status
+    }
+    code := http.StatusOK
+    if rep.Status != StatusOK {
+        code = http.StatusServiceUnavailable
+    }
+    c.W.Header().Set("Cache-Control", "no-store")
+    c.JSON(code, rep)
+}
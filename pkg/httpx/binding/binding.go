@@ -35,6 +35,27 @@ func BindQuery(r *http.Request, dst any) error {
     return queryDecoder.Decode(dst, r.URL.Query())
 }
 
+// ValidationErrors maps field names to messages; it is returned as an error
+// by ValidateStruct so handlers can propagate it.
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Error() string {
+    return "validation failed"
+}
+
+// ValidateStruct is like Validate but reports field failures as a
+// ValidationErrors error, suited to error-returning handlers.
+func ValidateStruct(v any) error {
+    fields, err := Validate(v)
+    if err != nil {
+        return err
+    }
+    if len(fields) > 0 {
+        return ValidationErrors(fields)
+    }
+    return nil
+}
+
 // Validate runs struct validation and returns a map of field->message on failure.
 func Validate(v any) (map[string]string, error) {
     if err := validate.Struct(v); err != nil {
@@ -0,0 +1,299 @@
+package httpx
+
+import (
+    "compress/gzip"
+    "io"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+
+    "github.com/andybalholm/brotli"
+    "github.com/klauspost/compress/zstd"
+)
+
+// CompressOptions configures Compress.
+type CompressOptions struct {
+    // Encodings in server preference order, used to break ties between
+    // equally weighted Accept-Encoding entries. Defaults to zstd, br, gzip.
+    Encodings []string
+    // MinSize is the smallest body worth compressing; smaller responses are
+    // sent as-is. Defaults to 1024 bytes.
+    MinSize int
+    // SkipTypes lists Content-Type prefixes that are never compressed.
+    // Defaults to DefaultCompressSkipTypes.
+    SkipTypes []string
+}
+
+// DefaultCompressSkipTypes are already-compressed media types.
+var DefaultCompressSkipTypes = []string{
+    "image/", "video/", "audio/", "font/woff",
+    "application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+    "application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+}
+
+// Compress negotiates Accept-Encoding (zstd, br, gzip) and compresses
+// response bodies of at least MinSize bytes, skipping already-compressed
+// content types and responses that set Content-Encoding. It always sets
+// Vary: Accept-Encoding. Register it after Logger so the log line reports
+// both wire bytes and uncompressed bytes.
+func Compress(opts CompressOptions) Middleware {
+    if len(opts.Encodings) == 0 {
+        opts.Encodings = []string{"zstd", "br", "gzip"}
+    }
+    if opts.MinSize <= 0 {
+        opts.MinSize = 1024
+    }
+    if opts.SkipTypes == nil {
+        opts.SkipTypes = DefaultCompressSkipTypes
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            c.W.Header().Add("Vary", "Accept-Encoding")
+            enc := negotiateEncoding(c.R.Header.Get("Accept-Encoding"), opts.Encodings)
+            if enc == "" || c.R.Method == http.MethodHead {
+                next(c)
+                return
+            }
+            orig := c.W
+            cw := &compressWriter{ResponseWriter: orig, enc: enc, opts: &opts}
+            cw.rec, _ = findWriter[*statusRecorder](orig)
+            cw.idem, _ = findWriter[*idempotencyWriter](orig)
+            c.W = cw
+            defer func() {
+                c.W = orig
+                if p := recover(); p != nil {
+                    cw.abort()
+                    panic(p)
+                }
+                _ = cw.Close()
+            }()
+            next(c)
+        }
+    }
+}
+
+// compressWriter buffers the first MinSize bytes to decide whether to
+// compress, then streams through a pooled encoder.
+type compressWriter struct {
+    http.ResponseWriter
+    enc  string
+    opts *CompressOptions
+    rec  *statusRecorder    // underlying Logger recorder, if any
+    idem *idempotencyWriter // enclosing Idempotency, stores the plain body
+
+    status  int
+    buf     []byte
+    decided bool
+    w       io.WriteCloser // encoder, nil when passing through
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+    if cw.decided {
+        cw.ResponseWriter.WriteHeader(code)
+        return
+    }
+    if cw.status == 0 {
+        cw.status = code
+    }
+    // informational and bodiless responses are final right away
+    if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+        cw.decide(false)
+    }
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+    if cw.rec != nil {
+        cw.rec.raw += len(b)
+    }
+    if !cw.decided {
+        cw.buf = append(cw.buf, b...)
+        if len(cw.buf) < cw.opts.MinSize {
+            return len(b), nil
+        }
+        if err := cw.decide(true); err != nil {
+            return 0, err
+        }
+        return len(b), nil
+    }
+    if cw.w != nil {
+        if cw.idem != nil {
+            cw.idem.plain.Write(b)
+        }
+        return cw.w.Write(b)
+    }
+    return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends what is buffered so far (compressing it if eligible).
+func (cw *compressWriter) Flush() {
+    if !cw.decided {
+        _ = cw.decide(len(cw.buf) > 0)
+    }
+    if f, ok := cw.w.(interface{ Flush() error }); ok {
+        _ = f.Flush()
+    }
+    http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap supports http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter { return cw.ResponseWriter }
+
+// decide writes the header, choosing compression when want is true and the
+// response qualifies, then flushes the buffer.
+func (cw *compressWriter) decide(want bool) error {
+    cw.decided = true
+    h := cw.Header()
+    if cw.status == 0 {
+        cw.status = http.StatusOK
+    }
+    if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+        h.Set("Content-Type", http.DetectContentType(cw.buf))
+    }
+    if want && cw.compressible(h) {
+        h.Set("Content-Encoding", cw.enc)
+        h.Del("Content-Length")
+        h.Del("Accept-Ranges")
+        cw.w = getEncoder(cw.enc, cw.ResponseWriter)
+        if cw.rec != nil {
+            cw.rec.compressed = true
+        }
+        if cw.idem != nil {
+            cw.idem.encoded = true
+            cw.idem.plain.Write(cw.buf)
+        }
+    }
+    cw.ResponseWriter.WriteHeader(cw.status)
+    buf := cw.buf
+    cw.buf = nil
+    if len(buf) == 0 {
+        return nil
+    }
+    var err error
+    if cw.w != nil {
+        _, err = cw.w.Write(buf)
+    } else {
+        _, err = cw.ResponseWriter.Write(buf)
+    }
+    return err
+}
+
+func (cw *compressWriter) compressible(h http.Header) bool {
+    if cw.status < 200 || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified || cw.status == http.StatusPartialContent {
+        return false
+    }
+    if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+        return false
+    }
+    if strings.Contains(h.Get("Cache-Control"), "no-transform") {
+        return false
+    }
+    ct := strings.ToLower(h.Get("Content-Type"))
+    if ct == "image/svg+xml" || strings.HasPrefix(ct, "image/svg+xml;") {
+        return true
+    }
+    for _, p := range cw.opts.SkipTypes {
+        if strings.HasPrefix(ct, p) {
+            return false
+        }
+    }
+    return true
+}
+
+// Close flushes a small undecided body uncompressed and finishes the encoder.
+func (cw *compressWriter) Close() error {
+    if !cw.decided {
+        if err := cw.decide(false); err != nil {
+            return err
+        }
+    }
+    if cw.w == nil {
+        return nil
+    }
+    err := cw.w.Close()
+    putEncoder(cw.enc, cw.w)
+    cw.w = nil
+    return err
+}
+
+// abort drops buffered output after a panic so Recover can write its error.
+func (cw *compressWriter) abort() {
+    if !cw.decided {
+        cw.buf = nil
+        return
+    }
+    _ = cw.Close()
+}
+
+// negotiateEncoding picks the supported encoding with the highest q-value,
+// breaking ties by server preference. "identity" or no match returns "".
+func negotiateEncoding(header string, prefs []string) string {
+    if header == "" {
+        return ""
+    }
+    q := make(map[string]float64)
+    for _, part := range strings.Split(header, ",") {
+        name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        name = strings.ToLower(strings.TrimSpace(name))
+        weight := 1.0
+        if params != "" {
+            if _, p, err := mime.ParseMediaType("x/x;" + params); err == nil {
+                if v, ok := p["q"]; ok {
+                    if f, err := strconv.ParseFloat(v, 64); err == nil {
+                        weight = f
+                    }
+                }
+            }
+        }
+        q[name] = weight
+    }
+    best, bestQ := "", 0.0
+    for _, enc := range prefs {
+        w, ok := q[enc]
+        if !ok {
+            w, ok = q["*"]
+        }
+        if ok && w > bestQ {
+            best, bestQ = enc, w
+        }
+    }
+    return best
+}
+
+var (
+    gzipPool   = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression); return w }}
+    brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }}
+    zstdPool   = sync.Pool{New: func() any {
+        w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+        return w
+    }}
+)
+
+func getEncoder(enc string, w io.Writer) io.WriteCloser {
+    switch enc {
+    case "zstd":
+        zw := zstdPool.Get().(*zstd.Encoder)
+        zw.Reset(w)
+        return zw
+    case "br":
+        bw := brotliPool.Get().(*brotli.Writer)
+        bw.Reset(w)
+        return bw
+    default:
+        gw := gzipPool.Get().(*gzip.Writer)
+        gw.Reset(w)
+        return gw
+    }
+}
+
+func putEncoder(enc string, w io.WriteCloser) {
+    switch enc {
+    case "zstd":
+        zstdPool.Put(w)
+    case "br":
+        brotliPool.Put(w)
+    default:
+        gzipPool.Put(w)
+    }
+}
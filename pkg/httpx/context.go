@@ -2,6 +2,7 @@ package httpx
 
 import (
     "encoding/json"
+    "fmt"
     "log/slog"
     "net/http"
     
@@ -9,12 +10,28 @@ import (
 )
 
 // Context carries request-scoped state and helpers.
+//
+// Contexts are pooled and reused once the handler chain returns, so a
+// Context must not be retained or used from goroutines that outlive the
+// request; copy the values you need instead.
+//
+// Logger carries method and route, plus request_id (RequestID) and user_id
+// (SetUserID) once known, so every log line in a handler is correlated.
 type Context struct {
     W         http.ResponseWriter
     R         *http.Request
     Logger    *slog.Logger
     RequestID string
     Values    map[string]any
+
+    router    *Router
+    route     *Route
+    userID    string
+    cspNonce  string
+    csrfToken string
+    clientIP  string // set by RealIP
+    scheme    string
+    host      string
 }
 
 // JSON writes a JSON response with status code.
@@ -40,3 +57,69 @@ func (c *Context) Error(status int, message string) {
 func (c *Context) Param(name string) string {
     return chi.URLParam(c.R, name)
 }
+
+// Route returns the matched route, or nil outside a routed handler.
+func (c *Context) Route() *Route { return c.route }
+
+// URLFor builds the path of a named route, see Router.URL.
+func (c *Context) URLFor(name string, pairs ...string) (string, error) {
+    if c.router == nil {
+        return "", fmt.Errorf("httpx: no router bound to context")
+    }
+    return c.router.URL(name, pairs...)
+}
+
+// requestID returns the ID set by the RequestID middleware, falling back to
+// the inbound X-Request-ID header.
+func (c *Context) requestID() string {
+    if c.RequestID != "" {
+        return c.RequestID
+    }
+    return c.R.Header.Get("X-Request-ID")
+}
+
+// SetUserID records the authenticated user for the request, typically from an
+// auth middleware. It keys KeyByUser rate limits and adds user_id to
+// c.Logger.
+func (c *Context) SetUserID(id string) {
+    c.userID = id
+    if c.Logger != nil && id != "" {
+        c.Logger = c.Logger.With(slog.String("user_id", id))
+    }
+}
+
+// UserID returns the ID set by SetUserID, or "".
+func (c *Context) UserID() string { return c.userID }
+
+// CSPNonce returns the per-request nonce generated by SecureHeaders when the
+// policy uses CSPNonce, or "".
+func (c *Context) CSPNonce() string { return c.cspNonce }
+
+// ClientIP returns the client IP resolved by RealIP, or the direct peer's IP.
+func (c *Context) ClientIP() string {
+    if c.clientIP != "" {
+        return c.clientIP
+    }
+    return remoteIP(c.R)
+}
+
+// Scheme returns "https" or "http", honouring forwarded proto from trusted
+// proxies (see RealIP).
+func (c *Context) Scheme() string {
+    if c.scheme != "" {
+        return c.scheme
+    }
+    if c.R.TLS != nil {
+        return "https"
+    }
+    return "http"
+}
+
+// Host returns the requested host, honouring forwarded host from trusted
+// proxies (see RealIP).
+func (c *Context) Host() string {
+    if c.host != "" {
+        return c.host
+    }
+    return c.R.Host
+}
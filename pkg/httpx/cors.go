@@ -0,0 +1,182 @@
+package httpx
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/config"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+    // AllowedOrigins lists exact origins ("https://app.example.com"),
+    // wildcard subdomains ("https://*.example.com") or "*" for any origin.
+    AllowedOrigins []string
+    // AllowOriginFunc, when set, is consulted for origins not matched above.
+    AllowOriginFunc func(origin string) bool
+    // AllowedMethods defaults to GET, HEAD, POST, PUT, PATCH, DELETE.
+    AllowedMethods []string
+    // AllowedHeaders defaults to Accept, Authorization, Content-Type,
+    // X-Request-ID. "*" allows any requested header.
+    AllowedHeaders []string
+    // ExposedHeaders are readable by browser scripts on actual responses.
+    ExposedHeaders   []string
+    AllowCredentials bool
+    // MaxAge lets browsers cache preflight results; 0 omits the header.
+    MaxAge time.Duration
+}
+
+// CORSOptionsFromConfig maps CORS_* config keys to options.
+func CORSOptionsFromConfig(c config.CORSConfig) CORSOptions {
+    return CORSOptions{
+        AllowedOrigins:   c.AllowedOrigins,
+        AllowedMethods:   c.AllowedMethods,
+        AllowedHeaders:   c.AllowedHeaders,
+        ExposedHeaders:   c.ExposedHeaders,
+        AllowCredentials: c.AllowCredentials,
+        MaxAge:           time.Duration(c.MaxAgeSec) * time.Second,
+    }
+}
+
+// CORS handles cross-origin requests and answers preflights (OPTIONS with
+// Access-Control-Request-Method) with 204, even for routes that don't
+// register OPTIONS. Register it on the root router so it also sees the
+// 404/405 fallbacks preflights land on.
+func CORS(opts CORSOptions) Middleware {
+    methods := opts.AllowedMethods
+    if len(methods) == 0 {
+        methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+    }
+    headers := opts.AllowedHeaders
+    if len(headers) == 0 {
+        headers = []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"}
+    }
+    anyOrigin, anyHeader := contains(opts.AllowedOrigins, "*"), contains(headers, "*")
+    allowMethods := strings.ToUpper(strings.Join(methods, ", "))
+    allowHeaders := strings.Join(headers, ", ")
+    exposed := strings.Join(opts.ExposedHeaders, ", ")
+    maxAge := ""
+    if opts.MaxAge > 0 {
+        maxAge = strconv.Itoa(int(opts.MaxAge / time.Second))
+    }
+
+    originAllowed := func(origin string) bool {
+        if anyOrigin {
+            return true
+        }
+        for _, o := range opts.AllowedOrigins {
+            if matchOrigin(o, origin) {
+                return true
+            }
+        }
+        return opts.AllowOriginFunc != nil && opts.AllowOriginFunc(origin)
+    }
+
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            origin := c.R.Header.Get("Origin")
+            h := c.W.Header()
+            preflight := c.R.Method == http.MethodOptions && c.R.Header.Get("Access-Control-Request-Method") != ""
+            if origin == "" {
+                next(c)
+                return
+            }
+            h.Add("Vary", "Origin")
+            if preflight {
+                h.Add("Vary", "Access-Control-Request-Method")
+                h.Add("Vary", "Access-Control-Request-Headers")
+            }
+            if !originAllowed(origin) {
+                if preflight {
+                    // no CORS headers: the browser blocks the actual request
+                    c.W.WriteHeader(http.StatusNoContent)
+                    return
+                }
+                next(c)
+                return
+            }
+
+            // Echo the origin unless "*" is allowed and no credentials are
+            // involved; browsers reject "*" with credentials.
+            if anyOrigin && !opts.AllowCredentials {
+                h.Set("Access-Control-Allow-Origin", "*")
+            } else {
+                h.Set("Access-Control-Allow-Origin", origin)
+            }
+            if opts.AllowCredentials {
+                h.Set("Access-Control-Allow-Credentials", "true")
+            }
+
+            if !preflight {
+                if exposed != "" {
+                    h.Set("Access-Control-Expose-Headers", exposed)
+                }
+                next(c)
+                return
+            }
+
+            reqMethod := strings.ToUpper(c.R.Header.Get("Access-Control-Request-Method"))
+            if !containsFold(methods, reqMethod) {
+                c.W.WriteHeader(http.StatusNoContent)
+                return
+            }
+            reqHeaders := c.R.Header.Get("Access-Control-Request-Headers")
+            if anyHeader {
+                if reqHeaders != "" {
+                    h.Set("Access-Control-Allow-Headers", reqHeaders)
+                }
+            } else {
+                for _, rh := range strings.Split(reqHeaders, ",") {
+                    if rh = strings.TrimSpace(rh); rh != "" && !containsFold(headers, rh) {
+                        c.W.WriteHeader(http.StatusNoContent)
+                        return
+                    }
+                }
+                h.Set("Access-Control-Allow-Headers", allowHeaders)
+            }
+            h.Set("Access-Control-Allow-Methods", allowMethods)
+            if maxAge != "" {
+                h.Set("Access-Control-Max-Age", maxAge)
+            }
+            c.W.WriteHeader(http.StatusNoContent)
+        }
+    }
+}
+
+// matchOrigin matches origin against an allowed entry, which may contain a
+// "*." wildcard for subdomains ("https://*.example.com" matches
+// "https://api.example.com" but not "https://example.com").
+func matchOrigin(allowed, origin string) bool {
+    if strings.EqualFold(allowed, origin) {
+        return true
+    }
+    i := strings.Index(allowed, "*.")
+    if i < 0 {
+        return false
+    }
+    prefix, suffix := allowed[:i], allowed[i+1:]
+    lo := strings.ToLower(origin)
+    return len(lo) > len(prefix)+len(suffix) &&
+        strings.HasPrefix(lo, strings.ToLower(prefix)) &&
+        strings.HasSuffix(lo, strings.ToLower(suffix))
+}
+
+func contains(list []string, s string) bool {
+    for _, v := range list {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
+
+func containsFold(list []string, s string) bool {
+    for _, v := range list {
+        if strings.EqualFold(v, s) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,166 @@
+package httpx
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/base64"
+    "mime"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// CSRFOptions configures CSRF.
+type CSRFOptions struct {
+    // Secret, when set, HMAC-signs the cookie token so a value planted by a
+    // sibling subdomain (cookie tossing) is rejected. Use a stable app key.
+    Secret []byte
+    // CookieName defaults to "_csrf".
+    CookieName string
+    // Header defaults to "X-CSRF-Token".
+    Header string
+    // FormField defaults to "_token".
+    FormField string
+    // Exempt lists paths or route patterns that skip the check, e.g.
+    // "/webhooks/stripe" or "/api/*" (trailing * matches a prefix).
+    Exempt []string
+    // SameSite defaults to Lax. MaxAge of 0 makes a session cookie.
+    SameSite http.SameSite
+    MaxAge   time.Duration
+}
+
+// CSRF protects unsafe requests (anything but GET, HEAD, OPTIONS and TRACE)
+// with a double-submit token: a cookie holds the token and the request must
+// echo it in the X-CSRF-Token header or the _token form field. Mismatches
+// get 419 (xerr csrf_mismatch). Templates read the token via
+// Context.CSRFToken:
+//
+//  <input type="hidden" name="_token" value="{{.CSRFToken}}">
+func CSRF(opts CSRFOptions) Middleware {
+    if opts.CookieName == "" {
+        opts.CookieName = "_csrf"
+    }
+    if opts.Header == "" {
+        opts.Header = "X-CSRF-Token"
+    }
+    if opts.FormField == "" {
+        opts.FormField = "_token"
+    }
+    if opts.SameSite == 0 {
+        opts.SameSite = http.SameSiteLaxMode
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            token := ""
+            if ck, err := c.R.Cookie(opts.CookieName); err == nil {
+                token = opts.verify(ck.Value)
+            }
+            switch c.R.Method {
+            case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+            default:
+                if !opts.exempt(c) {
+                    sent := opts.submitted(c)
+                    if token == "" || sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+                        xerr.ErrCSRFMismatch("").RenderRequest(c.W, c.R, c.RequestID)
+                        return
+                    }
+                }
+            }
+            if token == "" {
+                token = newCSRFToken()
+                ck := &http.Cookie{
+                    Name:     opts.CookieName,
+                    Value:    opts.sign(token),
+                    Path:     "/",
+                    Secure:   c.Scheme() == "https",
+                    HttpOnly: true,
+                    SameSite: opts.SameSite,
+                }
+                if opts.MaxAge > 0 {
+                    ck.MaxAge = int(opts.MaxAge / time.Second)
+                }
+                http.SetCookie(c.W, ck)
+            }
+            c.csrfToken = token
+            next(c)
+        }
+    }
+}
+
+// CSRFToken returns the request's CSRF token for forms and meta tags, or ""
+// when CSRF isn't in the chain.
+func (c *Context) CSRFToken() string { return c.csrfToken }
+
+// submitted returns the token from the header or, for form posts, the form
+// field. JSON and other bodies are not read.
+func (o CSRFOptions) submitted(c *Context) string {
+    if t := c.R.Header.Get(o.Header); t != "" {
+        return t
+    }
+    ct, _, _ := mime.ParseMediaType(c.R.Header.Get("Content-Type"))
+    if ct == "application/x-www-form-urlencoded" || ct == "multipart/form-data" {
+        return c.R.PostFormValue(o.FormField)
+    }
+    return ""
+}
+
+func (o CSRFOptions) exempt(c *Context) bool {
+    pattern := ""
+    if rt := c.Route(); rt != nil {
+        pattern = rt.Pattern()
+    }
+    for _, e := range o.Exempt {
+        if p, ok := strings.CutSuffix(e, "*"); ok {
+            if strings.HasPrefix(c.R.URL.Path, p) {
+                return true
+            }
+            continue
+        }
+        if e == c.R.URL.Path || e == pattern {
+            return true
+        }
+    }
+    return false
+}
+
+// sign appends an HMAC of token when a Secret is configured.
+func (o CSRFOptions) sign(token string) string {
+    if len(o.Secret) == 0 {
+        return token
+    }
+    return token + "." + o.mac(token)
+}
+
+// verify returns the token carried by a cookie value, or "" if it's
+// malformed or its signature doesn't match.
+func (o CSRFOptions) verify(v string) string {
+    if len(o.Secret) == 0 {
+        if strings.Contains(v, ".") {
+            return ""
+        }
+        return v
+    }
+    token, sig, ok := strings.Cut(v, ".")
+    if !ok || !hmac.Equal([]byte(sig), []byte(o.mac(token))) {
+        return ""
+    }
+    return token
+}
+
+func (o CSRFOptions) mac(token string) string {
+    m := hmac.New(sha256.New, o.Secret)
+    m.Write([]byte(token))
+    return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func newCSRFToken() string {
+    var b [32]byte
+    if _, err := rand.Read(b[:]); err != nil {
+        panic("httpx: crypto/rand failed: " + err.Error())
+    }
+    return base64.RawURLEncoding.EncodeToString(b[:])
+}
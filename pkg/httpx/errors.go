@@ -0,0 +1,84 @@
+package httpx
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/binding"
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// StatusClientClosedRequest is the non-standard status (nginx's 499) recorded
+// when the client went away before the handler finished.
+const StatusClientClosedRequest = 499
+
+// HandlerFuncE is a handler that returns an error instead of writing it.
+// Returned errors are rendered by the router's ErrorHandler, so return them
+// before writing anything to the response.
+type HandlerFuncE func(*Context) error
+
+// ErrorHandler renders an error returned by a HandlerFuncE.
+type ErrorHandler func(c *Context, err error)
+
+// E adapts an error-returning handler to HandlerFunc, e.g. r.GET("/x", httpx.E(h)).
+func E(h HandlerFuncE) HandlerFunc {
+    return func(c *Context) {
+        if err := h(c); err != nil {
+            c.handleError(err)
+        }
+    }
+}
+
+// HandleE registers an error-returning handler for method and path.
+func (r *Router) HandleE(method, path string, h HandlerFuncE) *Route {
+    return r.Handle(method, path, E(h))
+}
+
+// SetErrorHandler replaces the ErrorHandler used for all routes of the router
+// tree. Passing nil restores DefaultErrorHandler.
+func (r *Router) SetErrorHandler(eh ErrorHandler) { r.root().errorHandler = eh }
+
+// DefaultErrorHandler maps errors to responses:
+//   - *http.MaxBytesError (body over BodyLimit) renders 413
+//   - *xerr.HTTPError (also wrapped) renders with its own status and code
+//   - binding.ValidationErrors renders 422 validation_failed
+//   - context.Canceled records 499 (the client is gone, nothing is sent)
+//   - context.DeadlineExceeded renders 503 timeout
+//   - anything else renders as 500 with the request ID
+//
+// 5xx errors are logged with their cause.
+func DefaultErrorHandler(c *Context, err error) {
+    var he *xerr.HTTPError
+    var ve binding.ValidationErrors
+    var mbe *http.MaxBytesError
+    switch {
+    case errors.As(err, &mbe):
+        he = xerr.ErrPayloadTooLarge(fmt.Sprintf("request body exceeds %d bytes", mbe.Limit))
+    case errors.As(err, &he):
+    case errors.As(err, &ve):
+        he = xerr.ErrValidation(ve)
+    case errors.Is(err, context.Canceled):
+        c.W.WriteHeader(StatusClientClosedRequest)
+        return
+    case errors.Is(err, context.DeadlineExceeded):
+        he = xerr.ErrTimeout("request timed out").Wrap(err)
+    default:
+        he = xerr.ErrInternal("").Wrap(err)
+    }
+    if he.Status >= 500 && c.Logger != nil {
+        c.Logger.Error("handler_error", slog.String("error", err.Error()))
+    }
+    xerr.WriteRequest(c.W, c.R, c.RequestID, he)
+}
+
+// handleError dispatches err to the router's ErrorHandler.
+func (c *Context) handleError(err error) {
+    if c.router != nil && c.router.errorHandler != nil {
+        c.router.errorHandler(c, err)
+        return
+    }
+    DefaultErrorHandler(c, err)
+}
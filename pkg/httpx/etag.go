@@ -0,0 +1,220 @@
+package httpx
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// ETagOptions configures ETag.
+type ETagOptions struct {
+    // Strong emits strong validators. The default is weak (W/"..."), which
+    // stays valid when Compress re-encodes the body.
+    Strong bool
+    // MaxSize bounds the buffered body; larger responses are streamed without
+    // an ETag. Defaults to 1MB.
+    MaxSize int
+}
+
+// ETag buffers successful GET/HEAD responses, tags them with a hash of the
+// body (unless the handler set ETag itself) and answers If-None-Match or
+// If-Modified-Since (against a handler-set Last-Modified) with 304.
+// Place it inside Compress so the hash covers the uncompressed body.
+//
+// For If-Match on PUT/DELETE, handlers call Context.IfMatch with the
+// resource's current tag.
+func ETag(opts ETagOptions) Middleware {
+    if opts.MaxSize <= 0 {
+        opts.MaxSize = 1 << 20
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            if c.R.Method != http.MethodGet && c.R.Method != http.MethodHead {
+                next(c)
+                return
+            }
+            ew := &etagWriter{ResponseWriter: c.W, max: opts.MaxSize}
+            c.W = ew
+            defer func() { c.W = ew.ResponseWriter }()
+            next(c)
+            if ew.passthrough {
+                return
+            }
+            c.W = ew.ResponseWriter
+            h := c.W.Header()
+            if ew.status == 0 {
+                ew.status = http.StatusOK
+            }
+            if ew.status == http.StatusOK {
+                if h.Get("ETag") == "" {
+                    sum := sha256.Sum256(ew.buf.Bytes())
+                    h.Set("ETag", formatETag(hex.EncodeToString(sum[:16]), !opts.Strong))
+                }
+                if c.NotModified() {
+                    return
+                }
+            }
+            c.W.WriteHeader(ew.status)
+            _, _ = c.W.Write(ew.buf.Bytes())
+        }
+    }
+}
+
+// SetETag sets the response ETag; tag is quoted (and W/-prefixed when weak).
+func (c *Context) SetETag(tag string, weak bool) {
+    c.W.Header().Set("ETag", formatETag(tag, weak))
+}
+
+// SetLastModified sets the Last-Modified response header.
+func (c *Context) SetLastModified(t time.Time) {
+    c.W.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// NotModified checks If-None-Match and If-Modified-Since against the ETag and
+// Last-Modified already set on the response. When the client's copy is fresh
+// it writes 304 and returns true, so handlers can skip building the body:
+//
+//  c.SetETag(strconv.Itoa(post.Version), false)
+//  if c.NotModified() { return }
+func (c *Context) NotModified() bool {
+    if c.R.Method != http.MethodGet && c.R.Method != http.MethodHead {
+        return false
+    }
+    h := c.W.Header()
+    fresh := false
+    if inm := c.R.Header.Get("If-None-Match"); inm != "" {
+        fresh = etagMatch(inm, h.Get("ETag"), true)
+    } else if ims := c.R.Header.Get("If-Modified-Since"); ims != "" {
+        lm, err1 := http.ParseTime(h.Get("Last-Modified"))
+        since, err2 := http.ParseTime(ims)
+        fresh = err1 == nil && err2 == nil && !lm.Truncate(time.Second).After(since)
+    }
+    if !fresh {
+        return false
+    }
+    for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+        h.Del(k)
+    }
+    c.W.WriteHeader(http.StatusNotModified)
+    return true
+}
+
+// IfMatch enforces an If-Match precondition against the resource's current
+// tag (as passed to SetETag; "" when the resource doesn't exist). On mismatch
+// it renders 412 and returns false. Requests without If-Match pass.
+//
+//  if !c.IfMatch(strconv.Itoa(post.Version)) { return }
+func (c *Context) IfMatch(current string) bool {
+    im := c.R.Header.Get("If-Match")
+    if im == "" {
+        return true
+    }
+    if current != "" && etagMatch(im, formatETag(current, false), false) {
+        return true
+    }
+    xerr.ErrPreconditionFailed("resource has been modified").RenderRequest(c.W, c.R, c.requestID())
+    return false
+}
+
+func formatETag(tag string, weak bool) string {
+    if !strings.HasPrefix(tag, `"`) {
+        tag = `"` + tag + `"`
+    }
+    if weak {
+        return "W/" + tag
+    }
+    return tag
+}
+
+// etagMatch reports whether header (a list of tags or "*") matches tag,
+// using weak comparison for If-None-Match and strong for If-Match.
+func etagMatch(header, tag string, weak bool) bool {
+    if tag == "" {
+        return false
+    }
+    if strings.TrimSpace(header) == "*" {
+        return true
+    }
+    if !weak && strings.HasPrefix(tag, "W/") {
+        return false
+    }
+    tag = strings.TrimPrefix(tag, "W/")
+    for _, t := range strings.Split(header, ",") {
+        t = strings.TrimSpace(t)
+        if strings.HasPrefix(t, "W/") {
+            if !weak {
+                continue
+            }
+            t = t[2:]
+        }
+        if t == tag {
+            return true
+        }
+    }
+    return false
+}
+
+// etagWriter buffers the body for hashing, switching to passthrough when it
+// outgrows max or the handler flushes.
+type etagWriter struct {
+    http.ResponseWriter
+    buf         bytes.Buffer
+    status      int
+    max         int
+    passthrough bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+    if w.passthrough {
+        w.ResponseWriter.WriteHeader(code)
+        return
+    }
+    if w.status == 0 {
+        w.status = code
+    }
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+    if w.passthrough {
+        return w.ResponseWriter.Write(b)
+    }
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+    if w.buf.Len()+len(b) > w.max {
+        if err := w.spill(); err != nil {
+            return 0, err
+        }
+        return w.ResponseWriter.Write(b)
+    }
+    return w.buf.Write(b)
+}
+
+// spill sends the buffered response and streams the rest.
+func (w *etagWriter) spill() error {
+    w.passthrough = true
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+    w.ResponseWriter.WriteHeader(w.status)
+    _, err := w.ResponseWriter.Write(w.buf.Bytes())
+    w.buf.Reset()
+    return err
+}
+
+func (w *etagWriter) Flush() {
+    if !w.passthrough {
+        _ = w.spill()
+    }
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Unwrap supports http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
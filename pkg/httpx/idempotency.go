@@ -0,0 +1,252 @@
+package httpx
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// IdempotencyRecord is what a store keeps per key.
+type IdempotencyRecord struct {
+    Fingerprint string
+    Done        bool // false while the first request is still executing
+    Status      int
+    Header      http.Header
+    Body        []byte
+}
+
+// IdempotencyStore persists idempotency keys. Begin must be atomic per key:
+// it either reserves key for the caller (returning nil) or returns the
+// existing, unexpired record.
+type IdempotencyStore interface {
+    Begin(ctx context.Context, key, fingerprint string, now, lockUntil time.Time) (*IdempotencyRecord, error)
+    Complete(ctx context.Context, key string, rec IdempotencyRecord, expires time.Time) error
+    Release(ctx context.Context, key string) error
+}
+
+// IdempotencyOptions configures Idempotency.
+type IdempotencyOptions struct {
+    // Store defaults to a new in-memory store, which only works per process.
+    Store IdempotencyStore
+    // TTL is how long completed responses are replayed. Defaults to 24h.
+    TTL time.Duration
+    // LockTimeout bounds how long an in-flight reservation blocks duplicates
+    // if its request never finishes (e.g. the process died). Defaults to 1m.
+    LockTimeout time.Duration
+    // Header defaults to "Idempotency-Key".
+    Header string
+    // Methods defaults to POST and PATCH.
+    Methods []string
+    // Required rejects requests without a key with 400.
+    Required bool
+    // Scope namespaces keys, e.g. KeyByUser() so clients can't collide.
+    Scope KeyFunc
+    // MaxBodyBytes caps the body buffered for the request fingerprint; larger
+    // bodies get 413. Defaults to 1MB; < 0 removes the cap.
+    MaxBodyBytes int64
+}
+
+// Idempotency gives requests carrying an Idempotency-Key exactly-once
+// semantics: the first request runs, concurrent duplicates get 409, and later
+// duplicates replay the stored status, headers and body (marked with
+// Idempotent-Replayed: true). Reusing a key for a different request
+// (method, path, query or body) returns 422. Server errors (5xx) and panics
+// release the key so the client can retry.
+func Idempotency(opts IdempotencyOptions) Middleware {
+    if opts.Store == nil {
+        opts.Store = NewMemoryIdempotencyStore()
+    }
+    if opts.TTL <= 0 {
+        opts.TTL = 24 * time.Hour
+    }
+    if opts.LockTimeout <= 0 {
+        opts.LockTimeout = time.Minute
+    }
+    if opts.Header == "" {
+        opts.Header = "Idempotency-Key"
+    }
+    if len(opts.Methods) == 0 {
+        opts.Methods = []string{http.MethodPost, http.MethodPatch}
+    }
+    if opts.MaxBodyBytes == 0 {
+        opts.MaxBodyBytes = 1 << 20
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            if !containsFold(opts.Methods, c.R.Method) {
+                next(c)
+                return
+            }
+            key := strings.TrimSpace(c.R.Header.Get(opts.Header))
+            if key == "" {
+                if opts.Required {
+                    xerr.ErrBadRequest(opts.Header+" header required").RenderRequest(c.W, c.R, c.RequestID)
+                    return
+                }
+                next(c)
+                return
+            }
+            if len(key) > 255 {
+                xerr.ErrBadRequest(opts.Header+" too long").RenderRequest(c.W, c.R, c.RequestID)
+                return
+            }
+            if opts.Scope != nil {
+                key = opts.Scope(c) + "|" + key
+            }
+            fp, err := fingerprint(c.W, c.R, opts.MaxBodyBytes)
+            if err != nil {
+                var mbe *http.MaxBytesError
+                if errors.As(err, &mbe) {
+                    xerr.ErrPayloadTooLarge(fmt.Sprintf("request body exceeds %d bytes", mbe.Limit)).RenderRequest(c.W, c.R, c.RequestID)
+                    return
+                }
+                c.handleError(err)
+                return
+            }
+
+            warn := func(err error) {
+                if c.Logger != nil {
+                    c.Logger.Warn("idempotency_store_error", slog.String("error", err.Error()))
+                }
+            }
+            ctx := context.WithoutCancel(c.R.Context())
+            now := time.Now()
+            rec, err := opts.Store.Begin(ctx, key, fp, now, now.Add(opts.LockTimeout))
+            if err != nil {
+                warn(err)
+                xerr.New(http.StatusServiceUnavailable, "unavailable", "idempotency store unavailable").
+                    RenderRequest(c.W, c.R, c.RequestID)
+                return
+            }
+            if rec != nil {
+                switch {
+                case rec.Fingerprint != fp:
+                    xerr.New(http.StatusUnprocessableEntity, "idempotency_key_reused",
+                        opts.Header+" was already used for a different request").RenderRequest(c.W, c.R, c.RequestID)
+                case !rec.Done:
+                    c.W.Header().Set("Retry-After", "1")
+                    xerr.ErrConflict("a request with this "+opts.Header+" is still in progress").
+                        RenderRequest(c.W, c.R, c.RequestID)
+                default:
+                    h := c.W.Header()
+                    for k, vv := range rec.Header {
+                        h[k] = vv
+                    }
+                    h.Set("Idempotent-Replayed", "true")
+                    c.W.WriteHeader(rec.Status)
+                    _, _ = c.W.Write(rec.Body)
+                }
+                return
+            }
+
+            iw := &idempotencyWriter{ResponseWriter: c.W}
+            c.W = iw
+            completed := false
+            defer func() {
+                c.W = iw.ResponseWriter
+                if !completed {
+                    // panic or 5xx: let the client retry
+                    if err := opts.Store.Release(ctx, key); err != nil {
+                        warn(err)
+                    }
+                }
+            }()
+            next(c)
+            if iw.status == 0 {
+                iw.status = http.StatusOK
+            }
+            if iw.status >= 500 {
+                return
+            }
+            completed = true
+            body := iw.body.Bytes()
+            if iw.encoded {
+                // store what the handler wrote; Compress encoded it for this
+                // client only, and replays bypass it
+                body = iw.plain.Bytes()
+                delete(iw.header, "Content-Encoding")
+            }
+            done := IdempotencyRecord{Fingerprint: fp, Done: true, Status: iw.status, Header: iw.header, Body: body}
+            if err := opts.Store.Complete(ctx, key, done, time.Now().Add(opts.TTL)); err != nil {
+                warn(err)
+            }
+        }
+    }
+}
+
+// fingerprint hashes method, path, query and body (reading at most max
+// bytes when max > 0), restoring the body for the handler.
+func fingerprint(w http.ResponseWriter, r *http.Request, max int64) (string, error) {
+    h := sha256.New()
+    io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+    if r.Body != nil && r.Body != http.NoBody {
+        body := r.Body
+        if max > 0 {
+            body = http.MaxBytesReader(w, body, max)
+        }
+        b, err := io.ReadAll(body)
+        if err != nil {
+            return "", err
+        }
+        r.Body = io.NopCloser(bytes.NewReader(b))
+        h.Write(b)
+    }
+    return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// idempotencySkipHeaders are per-response headers not worth replaying.
+// A Content-Encoding set by the handler is kept with its encoded body.
+var idempotencySkipHeaders = []string{"Date", "X-Request-Id", "Content-Length"}
+
+// idempotencyWriter tees the response to the client and a buffer. When
+// Compress runs inside Idempotency it fills plain with the unencoded body
+// and sets encoded, so replays don't depend on the first client's
+// Accept-Encoding.
+type idempotencyWriter struct {
+    http.ResponseWriter
+    status  int
+    header  http.Header
+    body    bytes.Buffer
+    plain   bytes.Buffer
+    encoded bool
+}
+
+func (w *idempotencyWriter) WriteHeader(code int) {
+    if w.status == 0 {
+        w.status = code
+        w.header = w.ResponseWriter.Header().Clone()
+        for _, k := range idempotencySkipHeaders {
+            delete(w.header, k)
+        }
+    }
+    w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.WriteHeader(http.StatusOK)
+    }
+    if !w.encoded {
+        w.body.Write(b)
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) Flush() {
+    if f, ok := w.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Unwrap supports http.ResponseController.
+func (w *idempotencyWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,65 @@
+package httpx
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// MemoryIdempotencyStore keeps keys in process memory. Keys are per replica;
+// use PostgresIdempotencyStore to share them.
+type MemoryIdempotencyStore struct {
+    mu        sync.Mutex
+    entries   map[string]*memoryIdempotencyEntry
+    lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+    rec     IdempotencyRecord
+    expires time.Time
+}
+
+// NewMemoryIdempotencyStore returns an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+    return &MemoryIdempotencyStore{entries: make(map[string]*memoryIdempotencyEntry)}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, now, lockUntil time.Time) (*IdempotencyRecord, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if now.Sub(s.lastSweep) > time.Minute {
+        for k, e := range s.entries {
+            if now.After(e.expires) {
+                delete(s.entries, k)
+            }
+        }
+        s.lastSweep = now
+    }
+    if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+        rec := e.rec
+        return &rec, nil
+    }
+    s.entries[key] = &memoryIdempotencyEntry{rec: IdempotencyRecord{Fingerprint: fingerprint}, expires: lockUntil}
+    return nil, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, rec IdempotencyRecord, expires time.Time) error {
+    rec.Header = rec.Header.Clone()
+    rec.Body = append([]byte(nil), rec.Body...)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.entries[key] = &memoryIdempotencyEntry{rec: rec, expires: expires}
+    return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if e, ok := s.entries[key]; ok && !e.rec.Done {
+        delete(s.entries, key)
+    }
+    return nil
+}
@@ -0,0 +1,109 @@
+package httpx
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// PostgresIdempotencyStore keeps keys in a Postgres table so duplicates are
+// caught across replicas. The table ships as a migration in new apps
+// (internal/db/migrations/0003_create_idempotency_keys.sql):
+//
+//  CREATE TABLE idempotency_keys (
+//      key         TEXT PRIMARY KEY,
+//      fingerprint TEXT NOT NULL,
+//      status      INTEGER NOT NULL DEFAULT 0, -- 0 while in flight
+//      headers     JSONB,
+//      body        BYTEA,
+//      expires_at  TIMESTAMPTZ NOT NULL
+//  );
+type PostgresIdempotencyStore struct {
+    db *sql.DB
+
+    mu        sync.Mutex
+    lastSweep time.Time
+}
+
+// NewPostgresIdempotencyStore returns a store using db (e.g. opened with the
+// pgx stdlib driver).
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+    return &PostgresIdempotencyStore{db: db}
+}
+
+// Begin implements IdempotencyStore. Reserving is a single upsert that only
+// takes over expired rows, so concurrent replicas can't both win.
+func (s *PostgresIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, now, lockUntil time.Time) (*IdempotencyRecord, error) {
+    s.sweep(ctx, now)
+    var k string
+    err := s.db.QueryRowContext(ctx,
+        `INSERT INTO idempotency_keys(key, fingerprint, status, expires_at) VALUES ($1, $2, 0, $3)
+         ON CONFLICT (key) DO UPDATE
+           SET fingerprint=EXCLUDED.fingerprint, status=0, headers=NULL, body=NULL, expires_at=EXCLUDED.expires_at
+           WHERE idempotency_keys.expires_at < $4
+         RETURNING key`,
+        key, fingerprint, lockUntil, now).Scan(&k)
+    if err == nil {
+        return nil, nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return nil, err
+    }
+    var (
+        rec     IdempotencyRecord
+        headers []byte
+    )
+    err = s.db.QueryRowContext(ctx,
+        `SELECT fingerprint, status, headers, body FROM idempotency_keys WHERE key=$1`,
+        key).Scan(&rec.Fingerprint, &rec.Status, &headers, &rec.Body)
+    if errors.Is(err, sql.ErrNoRows) {
+        // released between the two statements; try again
+        return s.Begin(ctx, key, fingerprint, now, lockUntil)
+    }
+    if err != nil {
+        return nil, err
+    }
+    rec.Done = rec.Status != 0
+    if len(headers) > 0 {
+        rec.Header = http.Header{}
+        if err := json.Unmarshal(headers, &rec.Header); err != nil {
+            return nil, err
+        }
+    }
+    return &rec, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, expires time.Time) error {
+    headers, err := json.Marshal(rec.Header)
+    if err != nil {
+        return err
+    }
+    _, err = s.db.ExecContext(ctx,
+        `UPDATE idempotency_keys SET status=$2, headers=$3, body=$4, expires_at=$5 WHERE key=$1`,
+        key, rec.Status, string(headers), rec.Body, expires)
+    return err
+}
+
+// Release implements IdempotencyStore.
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key string) error {
+    _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key=$1 AND status=0`, key)
+    return err
+}
+
+// sweep deletes expired rows at most once a minute per process.
+func (s *PostgresIdempotencyStore) sweep(ctx context.Context, now time.Time) {
+    s.mu.Lock()
+    due := now.Sub(s.lastSweep) > time.Minute
+    if due {
+        s.lastSweep = now
+    }
+    s.mu.Unlock()
+    if due {
+        _, _ = s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, now)
+    }
+}
@@ -0,0 +1,133 @@
+package httpx
+
+import (
+    "compress/gzip"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func idempotentPost(t *testing.T, h http.Handler, body, acceptEncoding string) *httptest.ResponseRecorder {
+    t.Helper()
+    req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+    req.Header.Set("Idempotency-Key", "k1")
+    if acceptEncoding != "" {
+        req.Header.Set("Accept-Encoding", acceptEncoding)
+    }
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, req)
+    return rec
+}
+
+func decodedBody(t *testing.T, rec *httptest.ResponseRecorder) string {
+    t.Helper()
+    var r io.Reader = rec.Body
+    if rec.Header().Get("Content-Encoding") == "gzip" {
+        zr, err := gzip.NewReader(rec.Body)
+        if err != nil {
+            t.Fatalf("gzip: %v", err)
+        }
+        r = zr
+    }
+    b, err := io.ReadAll(r)
+    if err != nil {
+        t.Fatalf("read body: %v", err)
+    }
+    return string(b)
+}
+
+func TestIdempotencyReplayWithCompress(t *testing.T) {
+    want := strings.Repeat("order created ", 200)
+    orders := []struct {
+        name string
+        mw   func() []Middleware
+    }{
+        {"compress inside", func() []Middleware {
+            return []Middleware{Idempotency(IdempotencyOptions{}), Compress(CompressOptions{})}
+        }},
+        {"compress outside", func() []Middleware {
+            return []Middleware{Compress(CompressOptions{}), Idempotency(IdempotencyOptions{})}
+        }},
+    }
+    retries := []struct {
+        first, retry string
+        wantEncoding []string // acceptable Content-Encoding values on replay
+    }{
+        {"zstd", "", []string{""}},
+        {"gzip", "", []string{""}},
+        {"zstd", "gzip", []string{"", "gzip"}},
+        {"", "gzip", []string{"", "gzip"}},
+    }
+    for _, o := range orders {
+        for _, rt := range retries {
+            t.Run(o.name+"/"+rt.first+"->"+rt.retry, func(t *testing.T) {
+                calls := 0
+                r := New()
+                r.Use(o.mw()...)
+                r.POST("/orders", func(c *Context) {
+                    calls++
+                    c.Text(http.StatusCreated, want)
+                })
+
+                idempotentPost(t, r, `{"sku":1}`, rt.first)
+                replay := idempotentPost(t, r, `{"sku":1}`, rt.retry)
+                if calls != 1 {
+                    t.Fatalf("handler ran %d times, want 1", calls)
+                }
+                if replay.Header().Get("Idempotent-Replayed") != "true" {
+                    t.Fatal("second response is not a replay")
+                }
+                if replay.Code != http.StatusCreated {
+                    t.Fatalf("replay status = %d, want %d", replay.Code, http.StatusCreated)
+                }
+                enc := replay.Header().Get("Content-Encoding")
+                ok := false
+                for _, w := range rt.wantEncoding {
+                    ok = ok || enc == w
+                }
+                if !ok {
+                    t.Fatalf("replay Content-Encoding = %q, want one of %q", enc, rt.wantEncoding)
+                }
+                if got := decodedBody(t, replay); got != want {
+                    t.Fatalf("replay body = %q, want %q", got, want)
+                }
+            })
+        }
+    }
+}
+
+func TestIdempotencyReplayKeepsHandlerEncoding(t *testing.T) {
+    var gz strings.Builder
+    zw := gzip.NewWriter(&gz)
+    zw.Write([]byte(strings.Repeat("precompressed ", 200)))
+    zw.Close()
+
+    r := New()
+    r.Use(Idempotency(IdempotencyOptions{}), Compress(CompressOptions{}))
+    r.POST("/orders", func(c *Context) {
+        c.W.Header().Set("Content-Type", "text/plain")
+        c.W.Header().Set("Content-Encoding", "gzip")
+        c.W.Write([]byte(gz.String()))
+    })
+    idempotentPost(t, r, "", "gzip")
+    replay := idempotentPost(t, r, "", "gzip")
+    if got := replay.Header().Get("Content-Encoding"); got != "gzip" {
+        t.Fatalf("replay Content-Encoding = %q, want gzip", got)
+    }
+    if got := decodedBody(t, replay); got != strings.Repeat("precompressed ", 200) {
+        t.Fatalf("replay body = %q", got)
+    }
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+    r := New()
+    r.Use(Idempotency(IdempotencyOptions{MaxBodyBytes: 8}))
+    r.POST("/orders", func(c *Context) { t.Fatal("handler ran for an oversized body") })
+
+    rec := idempotentPost(t, r, "0123456789", "")
+    if rec.Code != http.StatusRequestEntityTooLarge {
+        t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+    }
+}
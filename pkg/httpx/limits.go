@@ -0,0 +1,162 @@
+package httpx
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// BodyLimit caps request bodies at n bytes (n <= 0 disables the limit).
+// Requests declaring a larger Content-Length get 413 right away; otherwise
+// reads past the limit fail with *http.MaxBytesError, which
+// DefaultErrorHandler renders as 413. Route.BodyLimit overrides n per route.
+func BodyLimit(n int64) Middleware {
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            limit := n
+            if rt := c.Route(); rt != nil && rt.bodyLimit != 0 {
+                limit = rt.bodyLimit
+            }
+            if limit <= 0 || c.R.Body == nil || c.R.Body == http.NoBody {
+                next(c)
+                return
+            }
+            if c.R.ContentLength > limit {
+                xerr.ErrPayloadTooLarge(fmt.Sprintf("request body exceeds %d bytes", limit)).RenderRequest(c.W, c.R, c.RequestID)
+                return
+            }
+            c.R.Body = http.MaxBytesReader(c.W, c.R.Body, limit)
+            next(c)
+        }
+    }
+}
+
+// Timeout gives handlers a deadline of d (d <= 0 disables it). The request
+// context is canceled at the deadline; if the handler hasn't finished by then
+// the client gets 503 timeout and later writes are discarded. Like
+// http.TimeoutHandler the response is buffered until the handler returns.
+//
+// Route.Timeout overrides d per route. Nested Timeout middleware can only
+// shorten the deadline, so prefer one global Timeout plus route overrides.
+func Timeout(d time.Duration) Middleware {
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            limit := d
+            if rt := c.Route(); rt != nil && rt.timeout != 0 {
+                limit = rt.timeout
+            }
+            if limit <= 0 {
+                next(c)
+                return
+            }
+            ctx, cancel := context.WithTimeout(c.R.Context(), limit)
+            defer cancel()
+
+            // The handler runs on its own copy of the Context: the pooled one
+            // is recycled as soon as we return, possibly before it finishes.
+            tw := &timeoutWriter{h: make(http.Header)}
+            cc := new(Context)
+            *cc = *c
+            cc.W, cc.R = tw, c.R.WithContext(ctx)
+            if c.Values != nil {
+                cc.Values = make(map[string]any, len(c.Values))
+                for k, v := range c.Values {
+                    cc.Values[k] = v
+                }
+            }
+
+            done := make(chan struct{})
+            panicked := make(chan any, 1)
+            go func() {
+                defer func() {
+                    if p := recover(); p != nil {
+                        panicked <- p
+                    }
+                }()
+                next(cc)
+                close(done)
+            }()
+
+            select {
+            case p := <-panicked:
+                panic(p)
+            case <-done:
+                tw.mu.Lock()
+                defer tw.mu.Unlock()
+                w, r := c.W, c.R
+                *c = *cc // keep state set downstream (user ID, logger...)
+                c.W, c.R = w, r
+                dst := c.W.Header()
+                for k, vv := range tw.h {
+                    dst[k] = vv
+                }
+                if tw.status == 0 {
+                    tw.status = http.StatusOK
+                }
+                c.W.WriteHeader(tw.status)
+                _, _ = c.W.Write(tw.buf.Bytes())
+            case <-ctx.Done():
+                tw.mu.Lock()
+                defer tw.mu.Unlock()
+                tw.timedOut = true
+                if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+                    xerr.ErrTimeout("request timed out").RenderRequest(c.W, c.R, c.RequestID)
+                } else {
+                    c.W.WriteHeader(StatusClientClosedRequest)
+                }
+            }
+        }
+    }
+}
+
+// BodyLimit overrides the BodyLimit middleware's limit for this route;
+// n < 0 removes the limit.
+func (rt *Route) BodyLimit(n int64) *Route {
+    rt.bodyLimit = n
+    return rt
+}
+
+// Timeout overrides the Timeout middleware's deadline for this route;
+// d < 0 removes it.
+func (rt *Route) Timeout(d time.Duration) *Route {
+    rt.timeout = d
+    return rt
+}
+
+// timeoutWriter buffers a handler's response until Timeout decides to send it.
+type timeoutWriter struct {
+    mu       sync.Mutex
+    h        http.Header
+    buf      bytes.Buffer
+    status   int
+    timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+    tw.mu.Lock()
+    defer tw.mu.Unlock()
+    if tw.timedOut || tw.status != 0 {
+        return
+    }
+    tw.status = code
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+    tw.mu.Lock()
+    defer tw.mu.Unlock()
+    if tw.timedOut {
+        return 0, http.ErrHandlerTimeout
+    }
+    if tw.status == 0 {
+        tw.status = http.StatusOK
+    }
+    return tw.buf.Write(b)
+}
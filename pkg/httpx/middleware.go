@@ -9,9 +9,12 @@ import (
     "net/http"
     "runtime/debug"
     "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
 )
 
-// RequestID sets a request ID header and stores it in Context.
+// RequestID sets a request ID header, stores it in Context and adds it to
+// c.Logger.
 func RequestID() Middleware {
     return func(next HandlerFunc) HandlerFunc {
         return func(c *Context) {
@@ -21,12 +24,17 @@ func RequestID() Middleware {
             }
             c.RequestID = rid
             c.W.Header().Set("X-Request-ID", rid)
+            if c.Logger != nil {
+                c.Logger = c.Logger.With(slog.String("request_id", rid))
+            }
             next(c)
         }
     }
 }
 
-// Logger logs request method, path, status, duration, and request id.
+// Logger logs path, status, bytes, duration and client ip; method, route,
+// request_id and user_id come from the request's Context.Logger.
+// Behind Compress, bytes is the wire size and bytes_uncompressed is logged too.
 func Logger() Middleware {
     return func(next HandlerFunc) HandlerFunc {
         return func(c *Context) {
@@ -39,14 +47,14 @@ func Logger() Middleware {
                 sr.status = http.StatusOK
             }
             attrs := []slog.Attr{
-                slog.String("method", c.R.Method),
                 slog.String("path", c.R.URL.Path),
                 slog.Int("status", sr.status),
                 slog.Int("bytes", sr.n),
                 slog.Duration("duration", dur),
+                slog.String("ip", c.ClientIP()),
             }
-            if c.RequestID != "" {
-                attrs = append(attrs, slog.String("request_id", c.RequestID))
+            if sr.compressed {
+                attrs = append(attrs, slog.Int("bytes_uncompressed", sr.raw))
             }
             c.Logger.LogAttrs(c.R.Context(), slog.LevelInfo, "http_request", attrs...)
         }
@@ -64,11 +72,8 @@ func Recover() Middleware {
                     fmt.Fprintf(&b, "panic: %v\n", r)
                     b.Write(debug.Stack())
                     c.Logger.Error("panic", slog.String("stack", b.String()))
-                    // Respond via unified envelope
-                    reqID := c.RequestID
-                    // write internal error
-                    // local import to avoid circulars
-                    httpxInternal(c.W, reqID)
+                    // Respond via unified envelope (or problem details)
+                    xerr.ErrInternal("").RenderRequest(c.W, c.R, c.RequestID)
                 }
             }()
             next(c)
@@ -83,11 +88,3 @@ func newID() string {
     }
     return hex.EncodeToString(b[:])
 }
-
-// indirection to avoid importing xerr at top level and risk cycles
-func httpxInternal(w http.ResponseWriter, reqID string) {
-    // inline minimal writer to avoid import cycle if packages change
-    w.Header().Set("Content-Type", "application/json; charset=utf-8")
-    w.WriteHeader(http.StatusInternalServerError)
-    _, _ = w.Write([]byte(`{"error":"internal","message":"Internal Server Error","request_id":"` + reqID + `"}`))
-}
@@ -0,0 +1,243 @@
+package httpx
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
+)
+
+// RateLimitAlgorithm selects how requests are counted.
+type RateLimitAlgorithm int
+
+const (
+    // TokenBucket refills Limit tokens per Window up to Burst, allowing short
+    // bursts while enforcing the average rate.
+    TokenBucket RateLimitAlgorithm = iota
+    // SlidingWindow approximates a rolling window by weighting the previous
+    // fixed window's count, avoiding bursts at window boundaries.
+    SlidingWindow
+)
+
+// RateLimitPolicy is the limit applied to a key.
+type RateLimitPolicy struct {
+    Algorithm RateLimitAlgorithm
+    Limit     int           // requests per Window
+    Window    time.Duration
+    Burst     int           // token bucket capacity, defaults to Limit
+}
+
+// RateLimitResult is the outcome of taking one request from a key's quota.
+type RateLimitResult struct {
+    Allowed    bool
+    Limit      int
+    Remaining  int
+    Reset      time.Duration // until the quota is fully restored
+    RetryAfter time.Duration // until the next request is allowed, when denied
+}
+
+// RateLimitStore persists per-key counters. Implementations must make Take
+// atomic per key.
+type RateLimitStore interface {
+    Take(ctx context.Context, key string, p RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
+
+// KeyFunc derives the rate limit key for a request. Returning "" skips
+// limiting for the request.
+type KeyFunc func(*Context) string
+
+// KeyByIP keys by client IP (see RealIP).
+func KeyByIP() KeyFunc {
+    return func(c *Context) string { return "ip:" + c.ClientIP() }
+}
+
+// KeyByUser keys by the authenticated user (Context.SetUserID), falling back
+// to the client IP for anonymous requests.
+func KeyByUser() KeyFunc {
+    return func(c *Context) string {
+        if id := c.UserID(); id != "" {
+            return "user:" + id
+        }
+        return "ip:" + c.ClientIP()
+    }
+}
+
+// KeyByRoute keys by route name (or pattern when unnamed), giving each route
+// one shared quota. Combine with Keys for per-client route limits.
+func KeyByRoute() KeyFunc {
+    return func(c *Context) string {
+        if rt := c.Route(); rt != nil {
+            if rt.name != "" {
+                return "route:" + rt.name
+            }
+            return "route:" + rt.method + " " + rt.pattern
+        }
+        return "route:" + c.R.URL.Path
+    }
+}
+
+// Keys joins several keys, e.g. Keys(KeyByRoute(), KeyByUser()).
+func Keys(fns ...KeyFunc) KeyFunc {
+    return func(c *Context) string {
+        parts := make([]string, 0, len(fns))
+        for _, fn := range fns {
+            k := fn(c)
+            if k == "" {
+                return ""
+            }
+            parts = append(parts, k)
+        }
+        return strings.Join(parts, "|")
+    }
+}
+
+// RateLimitOptions configures RateLimit.
+type RateLimitOptions struct {
+    RateLimitPolicy
+    // Key defaults to KeyByIP.
+    Key KeyFunc
+    // Store defaults to a new in-memory store, which only limits per process.
+    Store RateLimitStore
+    // Prefix namespaces keys when several limiters share a store.
+    Prefix string
+}
+
+// RateLimit rejects requests over quota with 429 (xerr rate_limited) and sets
+// RateLimit-Limit/-Remaining/-Reset/-Policy headers, plus Retry-After when
+// rejecting. Limit and Policy both advertise the window quota (Limit per
+// Window); a larger token bucket Burst only shows as extra headroom, with
+// Remaining capped at the quota. If the store fails the request is let
+// through and logged.
+func RateLimit(opts RateLimitOptions) Middleware {
+    if opts.Limit <= 0 || opts.Window <= 0 {
+        panic("httpx: RateLimit needs a positive Limit and Window")
+    }
+    if opts.Key == nil {
+        opts.Key = KeyByIP()
+    }
+    if opts.Store == nil {
+        opts.Store = NewMemoryRateLimitStore()
+    }
+    policy := opts.RateLimitPolicy
+    policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int(math.Ceil(policy.Window.Seconds())))
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            key := opts.Key(c)
+            if key == "" {
+                next(c)
+                return
+            }
+            res, err := opts.Store.Take(c.R.Context(), opts.Prefix+key, policy, time.Now())
+            if err != nil {
+                if c.Logger != nil {
+                    c.Logger.Warn("rate_limit_store_error", slog.String("error", err.Error()))
+                }
+                next(c)
+                return
+            }
+            h := c.W.Header()
+            h.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+            h.Set("RateLimit-Remaining", strconv.Itoa(min(res.Remaining, policy.Limit)))
+            h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+            h.Set("RateLimit-Policy", policyHeader)
+            if !res.Allowed {
+                h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+                xerr.ErrRateLimited("").RenderRequest(c.W, c.R, c.RequestID)
+                return
+            }
+            next(c)
+        }
+    }
+}
+
+// rateState is the per-key state shared by all stores.
+type rateState struct {
+    Tokens      float64
+    Last        time.Time
+    WindowStart time.Time
+    Curr, Prev  int
+}
+
+// take applies p to st at now, updating st in place.
+func (p RateLimitPolicy) take(st *rateState, now time.Time) RateLimitResult {
+    if p.Algorithm == SlidingWindow {
+        return p.takeWindow(st, now)
+    }
+    return p.takeBucket(st, now)
+}
+
+func (p RateLimitPolicy) takeBucket(st *rateState, now time.Time) RateLimitResult {
+    burst := p.Burst
+    if burst <= 0 {
+        burst = p.Limit
+    }
+    rate := float64(p.Limit) / p.Window.Seconds() // tokens per second
+    if st.Last.IsZero() {
+        st.Tokens = float64(burst)
+    } else if el := now.Sub(st.Last).Seconds(); el > 0 {
+        st.Tokens = math.Min(float64(burst), st.Tokens+el*rate)
+    }
+    st.Last = now
+    res := RateLimitResult{Limit: burst}
+    if st.Tokens >= 1 {
+        st.Tokens--
+        res.Allowed = true
+    } else {
+        res.RetryAfter = secondsDur((1 - st.Tokens) / rate)
+    }
+    res.Remaining = int(st.Tokens)
+    res.Reset = secondsDur((float64(burst) - st.Tokens) / rate)
+    return res
+}
+
+func (p RateLimitPolicy) takeWindow(st *rateState, now time.Time) RateLimitResult {
+    w := p.Window
+    start := now.Truncate(w)
+    switch {
+    case st.WindowStart.Equal(start):
+    case st.WindowStart.Equal(start.Add(-w)):
+        st.Prev, st.Curr = st.Curr, 0
+    default:
+        st.Prev, st.Curr = 0, 0
+    }
+    st.WindowStart = start
+    elapsed := now.Sub(start)
+    weight := 1 - float64(elapsed)/float64(w)
+    estimate := float64(st.Prev)*weight + float64(st.Curr)
+
+    res := RateLimitResult{Limit: p.Limit, Reset: w - elapsed}
+    if estimate+1 <= float64(p.Limit) {
+        st.Curr++
+        res.Allowed = true
+        res.Remaining = int(float64(p.Limit) - estimate - 1)
+        return res
+    }
+    // Time until the weighted previous window has decayed enough.
+    if st.Curr+1 <= p.Limit && st.Prev > 0 {
+        need := 1 - float64(p.Limit-st.Curr-1)/float64(st.Prev)
+        res.RetryAfter = time.Duration(need*float64(w)) - elapsed
+    } else {
+        // Only the next window helps; then the current count decays.
+        next := 0.0
+        if st.Curr > 0 {
+            next = math.Max(0, 1-float64(p.Limit-1)/float64(st.Curr))
+        }
+        res.RetryAfter = w - elapsed + time.Duration(next*float64(w))
+    }
+    if res.RetryAfter < 0 {
+        res.RetryAfter = 0
+    }
+    return res
+}
+
+// expiry is how long a key's state matters after its last use.
+func (p RateLimitPolicy) expiry() time.Duration { return 2 * p.Window }
+
+func secondsDur(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+
+func ceilSeconds(d time.Duration) int { return int(math.Ceil(d.Seconds())) }
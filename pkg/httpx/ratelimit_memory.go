@@ -0,0 +1,47 @@
+package httpx
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// MemoryRateLimitStore keeps counters in process memory. Limits are per
+// replica; use PostgresRateLimitStore to share them.
+type MemoryRateLimitStore struct {
+    mu        sync.Mutex
+    entries   map[string]*memoryRateEntry
+    lastSweep time.Time
+}
+
+type memoryRateEntry struct {
+    state   rateState
+    expires time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+    return &MemoryRateLimitStore{entries: make(map[string]*memoryRateEntry)}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, p RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if now.Sub(s.lastSweep) > time.Minute {
+        for k, e := range s.entries {
+            if now.After(e.expires) {
+                delete(s.entries, k)
+            }
+        }
+        s.lastSweep = now
+    }
+    e, ok := s.entries[key]
+    if !ok {
+        e = &memoryRateEntry{}
+        s.entries[key] = e
+    }
+    res := p.take(&e.state, now)
+    e.expires = now.Add(p.expiry())
+    return res, nil
+}
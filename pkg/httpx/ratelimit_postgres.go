@@ -0,0 +1,88 @@
+package httpx
+
+import (
+    "context"
+    "database/sql"
+    "sync"
+    "time"
+)
+
+// PostgresRateLimitStore keeps counters in a Postgres table so limits hold
+// across replicas. The table ships as a migration in new apps
+// (internal/db/migrations/0002_create_rate_limits.sql):
+//
+//  CREATE TABLE rate_limits (
+//      key          TEXT PRIMARY KEY,
+//      tokens       DOUBLE PRECISION NOT NULL DEFAULT 0,
+//      last_at      TIMESTAMPTZ,
+//      window_start TIMESTAMPTZ,
+//      curr         INTEGER NOT NULL DEFAULT 0,
+//      prev         INTEGER NOT NULL DEFAULT 0,
+//      expires_at   TIMESTAMPTZ NOT NULL
+//  );
+type PostgresRateLimitStore struct {
+    db *sql.DB
+
+    mu        sync.Mutex
+    lastSweep time.Time
+}
+
+// NewPostgresRateLimitStore returns a store using db (e.g. opened with the
+// pgx stdlib driver).
+func NewPostgresRateLimitStore(db *sql.DB) *PostgresRateLimitStore {
+    return &PostgresRateLimitStore{db: db}
+}
+
+// Take implements RateLimitStore. The key's row is locked for the duration of
+// a short transaction, so concurrent replicas serialize per key.
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, p RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+    s.sweep(ctx, now)
+    tx, err := s.db.BeginTx(ctx, nil)
+    if err != nil {
+        return RateLimitResult{}, err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx,
+        `INSERT INTO rate_limits(key, expires_at) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`,
+        key, now.Add(p.expiry())); err != nil {
+        return RateLimitResult{}, err
+    }
+    var (
+        st                rateState
+        last, windowStart sql.NullTime
+        expires           time.Time
+    )
+    if err := tx.QueryRowContext(ctx,
+        `SELECT tokens, last_at, window_start, curr, prev, expires_at FROM rate_limits WHERE key=$1 FOR UPDATE`,
+        key).Scan(&st.Tokens, &last, &windowStart, &st.Curr, &st.Prev, &expires); err != nil {
+        return RateLimitResult{}, err
+    }
+    if now.Before(expires) {
+        st.Last, st.WindowStart = last.Time, windowStart.Time
+    } else {
+        st = rateState{} // stale row: start over
+    }
+    res := p.take(&st, now)
+    if _, err := tx.ExecContext(ctx,
+        `UPDATE rate_limits SET tokens=$2, last_at=$3, window_start=$4, curr=$5, prev=$6, expires_at=$7 WHERE key=$1`,
+        key, st.Tokens, nullTime(st.Last), nullTime(st.WindowStart), st.Curr, st.Prev, now.Add(p.expiry())); err != nil {
+        return RateLimitResult{}, err
+    }
+    return res, tx.Commit()
+}
+
+// sweep deletes expired rows at most once a minute per process.
+func (s *PostgresRateLimitStore) sweep(ctx context.Context, now time.Time) {
+    s.mu.Lock()
+    due := now.Sub(s.lastSweep) > time.Minute
+    if due {
+        s.lastSweep = now
+    }
+    s.mu.Unlock()
+    if due {
+        _, _ = s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE expires_at < $1`, now)
+    }
+}
+
+func nullTime(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: !t.IsZero()} }
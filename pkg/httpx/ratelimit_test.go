@@ -0,0 +1,39 @@
+package httpx
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestRateLimitHeadersShareQuota(t *testing.T) {
+    for _, tc := range []struct {
+        name      string
+        policy    RateLimitPolicy
+        remaining string
+    }{
+        {"token bucket with burst", RateLimitPolicy{Algorithm: TokenBucket, Limit: 10, Window: time.Minute, Burst: 50}, "10"},
+        {"token bucket", RateLimitPolicy{Algorithm: TokenBucket, Limit: 10, Window: time.Minute}, "9"},
+        {"sliding window", RateLimitPolicy{Algorithm: SlidingWindow, Limit: 10, Window: time.Minute}, "9"},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            r := New()
+            r.Use(RateLimit(RateLimitOptions{RateLimitPolicy: tc.policy}))
+            r.GET("/", func(c *Context) { c.Text(http.StatusOK, "ok") })
+
+            rec := httptest.NewRecorder()
+            r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+            h := rec.Header()
+            if got := h.Get("RateLimit-Limit"); got != "10" {
+                t.Errorf("RateLimit-Limit = %q, want 10", got)
+            }
+            if got := h.Get("RateLimit-Policy"); got != "10;w=60" {
+                t.Errorf("RateLimit-Policy = %q, want 10;w=60", got)
+            }
+            if got := h.Get("RateLimit-Remaining"); got != tc.remaining {
+                t.Errorf("RateLimit-Remaining = %q, want %s", got, tc.remaining)
+            }
+        })
+    }
+}
@@ -0,0 +1,212 @@
+package httpx
+
+import (
+    "fmt"
+    "net"
+    "net/http"
+    "net/netip"
+    "strings"
+
+    "github.com/MohammedMogeab/largo/pkg/config"
+)
+
+// RealIPOptions configures RealIP.
+type RealIPOptions struct {
+    // TrustedProxies are CIDRs like "10.0.0.0/8" or bare IPs whose Header is
+    // honoured. Empty trusts no one.
+    TrustedProxies []string
+    // Header is the one header trusted for the client address:
+    // "X-Forwarded-For" (default; with X-Forwarded-Proto/Host), "Forwarded"
+    // (RFC 7239) or "X-Real-IP". Pick the one your proxy sets; the others are
+    // never consulted, as proxies pass client-sent copies through untouched.
+    Header string
+}
+
+// RealIPOptionsFromConfig maps TRUSTED_PROXIES and REAL_IP_HEADER to options.
+func RealIPOptionsFromConfig(c config.HTTPConfig) RealIPOptions {
+    return RealIPOptions{TrustedProxies: c.TrustedProxies, Header: c.RealIPHeader}
+}
+
+// RealIP resolves the client IP, scheme and host from the configured proxy
+// header when the direct peer is a trusted proxy. Headers from untrusted
+// peers are ignored, so they can't be spoofed by clients. The result is
+// exposed via Context.ClientIP, Scheme and Host.
+//
+// Chains are walked right to left, skipping trusted hops; the first
+// untrusted address is the client. RealIP panics on an invalid proxy entry
+// or unknown header, as those are startup misconfigurations.
+func RealIP(opts RealIPOptions) Middleware {
+    header := http.CanonicalHeaderKey(strings.TrimSpace(opts.Header))
+    switch header {
+    case "":
+        header = "X-Forwarded-For"
+    case "X-Forwarded-For", "Forwarded", "X-Real-Ip":
+    default:
+        panic(fmt.Sprintf("httpx: RealIP header %q not supported (want X-Forwarded-For, Forwarded or X-Real-IP)", opts.Header))
+    }
+    nets := make([]netip.Prefix, 0, len(opts.TrustedProxies))
+    for _, s := range opts.TrustedProxies {
+        s = strings.TrimSpace(s)
+        if s == "" {
+            continue
+        }
+        p, err := parseTrusted(s)
+        if err != nil {
+            panic(fmt.Sprintf("httpx: invalid trusted proxy %q: %v", s, err))
+        }
+        nets = append(nets, p)
+    }
+    isTrusted := func(a netip.Addr) bool {
+        a = a.Unmap()
+        for _, p := range nets {
+            if p.Contains(a) {
+                return true
+            }
+        }
+        return false
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(c *Context) {
+            peer, ok := parseHostIP(c.R.RemoteAddr)
+            if ok && isTrusted(peer) {
+                resolveForwarded(c, header, peer, isTrusted)
+            }
+            next(c)
+        }
+    }
+}
+
+// resolveForwarded reads only header; the peer stays the client when it's
+// absent.
+func resolveForwarded(c *Context, header string, peer netip.Addr, isTrusted func(netip.Addr) bool) {
+    h := c.R.Header
+    switch header {
+    case "Forwarded":
+        elems := parseForwarded(h.Values("Forwarded"))
+        client, i := walkChain(peer, len(elems), func(i int) string { return elems[i]["for"] }, isTrusted)
+        c.clientIP = client.String()
+        if i >= 0 {
+            c.scheme = strings.ToLower(elems[i]["proto"])
+            c.host = elems[i]["host"]
+        }
+    case "X-Forwarded-For":
+        hops := splitList(h.Values("X-Forwarded-For"))
+        client, i := walkChain(peer, len(hops), func(i int) string { return hops[i] }, isTrusted)
+        c.clientIP = client.String()
+        if i >= 0 {
+            c.scheme = strings.ToLower(lastValue(h.Get("X-Forwarded-Proto")))
+            c.host = lastValue(h.Get("X-Forwarded-Host"))
+        }
+    case "X-Real-Ip":
+        if a, ok := parseHostIP(h.Get("X-Real-IP")); ok {
+            c.clientIP = a.Unmap().String()
+        }
+    }
+}
+
+// walkChain walks n hops right to left starting behind peer and returns the
+// first untrusted address with its index. If every hop is trusted, the
+// leftmost wins; an unparseable hop stops the walk at the last good address.
+func walkChain(peer netip.Addr, n int, hop func(int) string, isTrusted func(netip.Addr) bool) (netip.Addr, int) {
+    client, idx := peer, -1
+    for i := n - 1; i >= 0; i-- {
+        a, ok := parseHostIP(hop(i))
+        if !ok {
+            break
+        }
+        client, idx = a, i
+        if !isTrusted(a) {
+            break
+        }
+    }
+    return client.Unmap(), idx
+}
+
+// parseForwarded splits RFC 7239 Forwarded values into elements of
+// lower-cased key -> unquoted value.
+func parseForwarded(values []string) []map[string]string {
+    var out []map[string]string
+    for _, v := range values {
+        for _, elem := range splitQuoted(v, ',') {
+            m := map[string]string{}
+            for _, pair := range splitQuoted(elem, ';') {
+                k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+                if !ok {
+                    continue
+                }
+                m[strings.ToLower(k)] = strings.Trim(strings.TrimSpace(val), `"`)
+            }
+            out = append(out, m)
+        }
+    }
+    return out
+}
+
+// splitQuoted splits s on sep outside of double quotes.
+func splitQuoted(s string, sep byte) []string {
+    var out []string
+    quoted, start := false, 0
+    for i := 0; i < len(s); i++ {
+        switch s[i] {
+        case '"':
+            quoted = !quoted
+        case sep:
+            if !quoted {
+                out = append(out, s[start:i])
+                start = i + 1
+            }
+        }
+    }
+    return append(out, s[start:])
+}
+
+func splitList(values []string) []string {
+    var out []string
+    for _, v := range values {
+        for _, p := range strings.Split(v, ",") {
+            out = append(out, strings.TrimSpace(p))
+        }
+    }
+    return out
+}
+
+func lastValue(v string) string {
+    if i := strings.LastIndexByte(v, ','); i >= 0 {
+        v = v[i+1:]
+    }
+    return strings.TrimSpace(v)
+}
+
+// parseHostIP parses "ip", "ip:port", "[ipv6]" or "[ipv6]:port".
+func parseHostIP(s string) (netip.Addr, bool) {
+    s = strings.TrimSpace(s)
+    if a, err := netip.ParseAddr(s); err == nil {
+        return a, true
+    }
+    if h, _, err := net.SplitHostPort(s); err == nil {
+        s = h
+    }
+    a, err := netip.ParseAddr(strings.Trim(s, "[]"))
+    return a, err == nil
+}
+
+func parseTrusted(s string) (netip.Prefix, error) {
+    if strings.Contains(s, "/") {
+        p, err := netip.ParsePrefix(s)
+        return p.Masked(), err
+    }
+    a, err := netip.ParseAddr(s)
+    if err != nil {
+        return netip.Prefix{}, err
+    }
+    a = a.Unmap()
+    return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// remoteIP returns the host part of RemoteAddr.
+func remoteIP(r *http.Request) string {
+    if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+        return h
+    }
+    return r.RemoteAddr
+}
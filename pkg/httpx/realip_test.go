@@ -0,0 +1,90 @@
+package httpx
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestRealIP(t *testing.T) {
+    tests := []struct {
+        name    string
+        header  string
+        peer    string
+        headers map[string]string
+        want    string
+    }{
+        {
+            name:    "spoofed Forwarded ignored by default",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"Forwarded": "for=9.9.9.9", "X-Forwarded-For": "1.1.1.1"},
+            want:    "1.1.1.1",
+        },
+        {
+            name:    "spoofed X-Real-IP ignored by default",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"X-Real-IP": "9.9.9.9"},
+            want:    "10.0.0.1",
+        },
+        {
+            name:    "client-sent X-Forwarded-For hop skipped",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"X-Forwarded-For": "9.9.9.9, 1.1.1.1, 10.0.0.2"},
+            want:    "1.1.1.1",
+        },
+        {
+            name:    "untrusted peer",
+            peer:    "203.0.113.7:1234",
+            headers: map[string]string{"X-Forwarded-For": "1.1.1.1"},
+            want:    "203.0.113.7",
+        },
+        {
+            name:    "Forwarded when chosen",
+            header:  "Forwarded",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https`, "X-Forwarded-For": "9.9.9.9"},
+            want:    "2001:db8::1",
+        },
+        {
+            name:    "X-Forwarded-For ignored when Forwarded chosen",
+            header:  "forwarded",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"X-Forwarded-For": "9.9.9.9"},
+            want:    "10.0.0.1",
+        },
+        {
+            name:    "X-Real-IP when chosen",
+            header:  "X-Real-IP",
+            peer:    "10.0.0.1:1234",
+            headers: map[string]string{"X-Real-IP": "1.1.1.1", "Forwarded": "for=9.9.9.9"},
+            want:    "1.1.1.1",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got string
+            r := New()
+            r.Use(RealIP(RealIPOptions{TrustedProxies: []string{"10.0.0.0/8"}, Header: tt.header}))
+            r.GET("/", func(c *Context) { got = c.ClientIP() })
+
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            req.RemoteAddr = tt.peer
+            for k, v := range tt.headers {
+                req.Header.Set(k, v)
+            }
+            r.ServeHTTP(httptest.NewRecorder(), req)
+            if got != tt.want {
+                t.Errorf("ClientIP = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRealIPUnknownHeaderPanics(t *testing.T) {
+    defer func() {
+        if recover() == nil {
+            t.Error("RealIP accepted an unsupported header")
+        }
+    }()
+    RealIP(RealIPOptions{Header: "X-Client-IP"})
+}
@@ -3,10 +3,14 @@ package httpx
 import "net/http"
 
 // statusRecorder wraps ResponseWriter to record status and bytes written.
+// When Compress sits inside it, n counts compressed (wire) bytes and raw the
+// uncompressed bytes handlers wrote.
 type statusRecorder struct {
     http.ResponseWriter
-    status int
-    n      int
+    status     int
+    n          int
+    raw        int
+    compressed bool
 }
 
 func (w *statusRecorder) WriteHeader(code int) {
@@ -23,3 +27,21 @@ func (w *statusRecorder) Write(b []byte) (int, error) {
     return n, err
 }
 
+// Unwrap supports http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// findWriter returns the first T beneath w, following Unwrap through
+// intermediate wrappers like http.ResponseController does.
+func findWriter[T http.ResponseWriter](w http.ResponseWriter) (T, bool) {
+    for {
+        if t, ok := w.(T); ok {
+            return t, true
+        }
+        u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+        if !ok {
+            var zero T
+            return zero, false
+        }
+        w = u.Unwrap()
+    }
+}
@@ -1,8 +1,12 @@
 package httpx
 
 import (
+    "fmt"
     "log/slog"
     "net/http"
+    "strings"
+    "sync"
+    "time"
 
     "github.com/go-chi/chi/v5"
     "github.com/MohammedMogeab/largo/pkg/httpx/xerr"
@@ -15,54 +19,243 @@ type HandlerFunc func(*Context)
 type Middleware func(HandlerFunc) HandlerFunc
 
 // Router uses chi under the hood and supports param routes.
+// Sub-routers created with Group or With share the same chi mux and
+// layer their own middleware on top of their parent's.
+//
+// Middleware chains are composed once when a route is registered; calling
+// Use after routes exist recomposes the affected routes. Configure the
+// router before serving: Use and Handle are not safe to call concurrently
+// with ServeHTTP.
 type Router struct {
-    mux         *chi.Mux
-    middlewares []Middleware
-    logger      *slog.Logger
+    mux          *chi.Mux
+    parent       *Router
+    prefix       string
+    middlewares  []Middleware
+    logger       *slog.Logger      // root router only, see SetLogger
+    routes       []*Route          // registry, only populated on the root router
+    names        map[string]*Route // named routes, root router only
+    errorHandler ErrorHandler      // root router only, see SetErrorHandler
+    fallbacks    []*Route          // 404/405 handlers, root router only
 }
 
+// Route is a registered endpoint with its precomposed middleware chain.
+type Route struct {
+    method    string
+    pattern   string
+    name      string
+    router    *Router
+    handler   HandlerFunc
+    chain     HandlerFunc
+    bodyLimit int64         // overrides BodyLimit when non-zero, <0 = unlimited
+    timeout   time.Duration // overrides Timeout when non-zero, <0 = none
+    logger    *slog.Logger  // root logger with method and route attrs
+}
+
+var ctxPool = sync.Pool{New: func() any { return new(Context) }}
+
 // New creates a Router with sensible defaults and JSON 404/405.
+// The 404/405 handlers run behind the root router's middleware, so
+// RequestID, Logger or CORS (answering preflights) apply to them too.
 func New() *Router {
     m := chi.NewRouter()
     r := &Router{mux: m, logger: slog.Default()}
     // JSON 404
-    m.NotFound(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-        rid := req.Header.Get("X-Request-ID")
-        xerr.NotFound(w, rid, "route not found")
+    m.NotFound(r.fallback(func(c *Context) {
+        xerr.ErrNotFound("route not found").RenderRequest(c.W, c.R, c.requestID())
     }))
     // JSON 405
-    m.MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-        rid := req.Header.Get("X-Request-ID")
-        xerr.MethodNotAllowed(w, rid, "")
+    m.MethodNotAllowed(r.fallback(func(c *Context) {
+        xerr.ErrMethodNotAllowed("").RenderRequest(c.W, c.R, c.requestID())
     }))
     return r
 }
 
-// Use appends middleware to the chain.
-func (r *Router) Use(mw ...Middleware) { r.middlewares = append(r.middlewares, mw...) }
+// Use appends middleware to the chain. Routes already registered on this
+// router or its sub-routers are recomposed to include it.
+func (r *Router) Use(mw ...Middleware) {
+    r.middlewares = append(r.middlewares, mw...)
+    root := r.root()
+    for _, rts := range [][]*Route{root.routes, root.fallbacks} {
+        for _, rt := range rts {
+            if rt.router.within(r) {
+                rt.chain = rt.router.compose(rt.handler)
+            }
+        }
+    }
+}
+
+// Group creates a sub-router whose routes are registered under prefix and
+// calls fn with it. Middleware added to the group (via g.Use) only applies
+// to routes registered on the group, after the parent's middleware.
+//
+//  r.Group("/admin", func(g *httpx.Router) {
+//      g.Use(auth)
+//      g.GET("/users", listUsers) // GET /admin/users
+//  })
+func (r *Router) Group(prefix string, fn func(g *Router)) *Router {
+    g := &Router{mux: r.mux, parent: r, prefix: joinPath(r.prefix, prefix)}
+    if fn != nil {
+        fn(g)
+    }
+    return g
+}
+
+// With returns a sub-router with the same prefix and additional middleware,
+// handy for one-off routes: r.With(auth).GET("/me", me).
+func (r *Router) With(mw ...Middleware) *Router {
+    g := &Router{mux: r.mux, parent: r, prefix: r.prefix}
+    g.middlewares = append(g.middlewares, mw...)
+    return g
+}
 
 // Handle registers a route for method and path (supports chi params e.g., /users/{id}).
-func (r *Router) Handle(method, path string, h HandlerFunc) {
-    r.mux.Method(method, path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-        // Build context
-        ctx := &Context{W: w, R: req, Logger: r.logger}
-        // Compose chain
-        final := h
-        for i := len(r.middlewares) - 1; i >= 0; i-- {
-            final = r.middlewares[i](final)
+// The returned Route can be named for reverse URL generation.
+func (r *Router) Handle(method, path string, h HandlerFunc) *Route {
+    rt := &Route{method: method, pattern: joinPath(r.prefix, path), router: r, handler: h}
+    rt.chain = r.compose(h)
+    root := r.root()
+    rt.logger = root.routeLogger(rt)
+    root.routes = append(root.routes, rt)
+    r.mux.Method(method, rt.pattern, r.serve(rt))
+    return rt
+}
+
+// fallback wraps a root-level handler (404/405) in the root middleware.
+func (r *Router) fallback(h HandlerFunc) http.HandlerFunc {
+    rt := &Route{router: r, handler: h, chain: r.compose(h)}
+    r.fallbacks = append(r.fallbacks, rt)
+    return r.serve(rt)
+}
+
+// SetLogger sets the logger handed to every request (as Context.Logger) for
+// the whole router tree. It defaults to slog.Default() at New time.
+func (r *Router) SetLogger(l *slog.Logger) {
+    if l == nil {
+        l = slog.Default()
+    }
+    root := r.root()
+    root.logger = l
+    for _, rt := range root.routes {
+        rt.logger = root.routeLogger(rt)
+    }
+}
+
+// Logger returns the router's base logger.
+func (r *Router) Logger() *slog.Logger { return r.root().logger }
+
+// routeLogger derives rt's request logger once, so serving doesn't allocate.
+func (r *Router) routeLogger(rt *Route) *slog.Logger {
+    return r.logger.With(slog.String("method", rt.method), slog.String("route", rt.pattern))
+}
+
+// serve adapts rt's chain to net/http using a pooled Context.
+func (r *Router) serve(rt *Route) http.HandlerFunc {
+    root := r.root()
+    return func(w http.ResponseWriter, req *http.Request) {
+        ctx := ctxPool.Get().(*Context)
+        ctx.W, ctx.R = w, req
+        ctx.router = root
+        if rt.method != "" {
+            ctx.route = rt
+            ctx.Logger = rt.logger
+        } else {
+            ctx.Logger = root.logger.With(slog.String("method", req.Method))
         }
-        final(ctx)
-    }))
+        rt.chain(ctx)
+        // not deferred: a panicking request simply doesn't recycle its Context
+        *ctx = Context{}
+        ctxPool.Put(ctx)
+    }
 }
 
 // GET registers a GET route.
-func (r *Router) GET(path string, h HandlerFunc)    { r.Handle(http.MethodGet, path, h) }
+func (r *Router) GET(path string, h HandlerFunc) *Route    { return r.Handle(http.MethodGet, path, h) }
 // POST registers a POST route.
-func (r *Router) POST(path string, h HandlerFunc)   { r.Handle(http.MethodPost, path, h) }
+func (r *Router) POST(path string, h HandlerFunc) *Route   { return r.Handle(http.MethodPost, path, h) }
 // PUT registers a PUT route.
-func (r *Router) PUT(path string, h HandlerFunc)    { r.Handle(http.MethodPut, path, h) }
+func (r *Router) PUT(path string, h HandlerFunc) *Route    { return r.Handle(http.MethodPut, path, h) }
 // DELETE registers a DELETE route.
-func (r *Router) DELETE(path string, h HandlerFunc) { r.Handle(http.MethodDelete, path, h) }
+func (r *Router) DELETE(path string, h HandlerFunc) *Route { return r.Handle(http.MethodDelete, path, h) }
+
+// Name assigns a unique name to the route, e.g. "users.show", so URLs can be
+// built with Router.URL or Context.URLFor. It panics if the name is taken.
+func (rt *Route) Name(name string) *Route {
+    root := rt.router.root()
+    if prev, ok := root.names[name]; ok && prev != rt {
+        panic(fmt.Sprintf("httpx: route name %q already used by %s %s", name, prev.method, prev.pattern))
+    }
+    if root.names == nil {
+        root.names = make(map[string]*Route)
+    }
+    if rt.name != "" {
+        delete(root.names, rt.name)
+    }
+    rt.name = name
+    root.names[name] = rt
+    return rt
+}
+
+// Method returns the HTTP method the route was registered for.
+func (rt *Route) Method() string { return rt.method }
+
+// Pattern returns the full chi pattern, including group prefixes.
+func (rt *Route) Pattern() string { return rt.pattern }
+
+// URL builds the path for the named route from key/value pairs, e.g.
+// r.URL("users.show", "id", "42") -> "/users/42". Pairs that don't match a
+// route param are appended as query string.
+func (r *Router) URL(name string, pairs ...string) (string, error) {
+    rt, ok := r.root().names[name]
+    if !ok {
+        return "", fmt.Errorf("httpx: no route named %q", name)
+    }
+    return buildURL(rt.pattern, pairs)
+}
 
 // ServeHTTP implements http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) { r.mux.ServeHTTP(w, req) }
+
+// compose wraps h with the middleware of r and its ancestors, innermost
+// (this router) first so the root's middleware runs outermost.
+func (r *Router) compose(h HandlerFunc) HandlerFunc {
+    final := h
+    for rr := r; rr != nil; rr = rr.parent {
+        for i := len(rr.middlewares) - 1; i >= 0; i-- {
+            final = rr.middlewares[i](final)
+        }
+    }
+    return final
+}
+
+func (r *Router) root() *Router {
+    for r.parent != nil {
+        r = r.parent
+    }
+    return r
+}
+
+// within reports whether r is anc or one of its sub-routers.
+func (r *Router) within(anc *Router) bool {
+    for ; r != nil; r = r.parent {
+        if r == anc {
+            return true
+        }
+    }
+    return false
+}
+
+// joinPath appends path to prefix, normalizing slashes. A "/" path inside a
+// group maps to the group prefix itself.
+func joinPath(prefix, path string) string {
+    prefix = strings.TrimRight(prefix, "/")
+    if path == "" || path == "/" {
+        if prefix == "" {
+            return "/"
+        }
+        return prefix
+    }
+    if !strings.HasPrefix(path, "/") {
+        path = "/" + path
+    }
+    return prefix + path
+}
@@ -0,0 +1,57 @@
+package httpx
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+// discardWriter is a reusable ResponseWriter so the benchmarks measure the
+// router rather than httptest.ResponseRecorder.
+type discardWriter struct{ h http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.h }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func benchRouter(b *testing.B, r *Router, path string) {
+    req := httptest.NewRequest(http.MethodGet, path, nil)
+    w := &discardWriter{h: make(http.Header)}
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        r.ServeHTTP(w, req)
+    }
+}
+
+func noopMiddleware(next HandlerFunc) HandlerFunc {
+    return func(c *Context) { next(c) }
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+    r := New()
+    r.GET("/users", func(c *Context) { c.W.WriteHeader(http.StatusOK) })
+    benchRouter(b, r, "/users")
+}
+
+func BenchmarkRouterParam(b *testing.B) {
+    r := New()
+    r.GET("/users/{id}", func(c *Context) {
+        _ = c.Param("id")
+        c.W.WriteHeader(http.StatusOK)
+    })
+    benchRouter(b, r, "/users/42")
+}
+
+func BenchmarkRouterGroupMiddleware(b *testing.B) {
+    r := New()
+    r.Use(noopMiddleware, noopMiddleware)
+    r.Group("/api", func(g *Router) {
+        g.Use(noopMiddleware)
+        g.With(noopMiddleware).GET("/users/{id}/posts/{post}", func(c *Context) {
+            _ = c.Param("post")
+            c.W.WriteHeader(http.StatusOK)
+        })
+    })
+    benchRouter(b, r, "/api/users/42/posts/7")
+}
@@ -0,0 +1,108 @@
+package httpx
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "path"
+    "reflect"
+    "runtime"
+    "strings"
+
+    "github.com/go-chi/chi/v5"
+)
+
+// RouteDumpEnv makes Serve/ServeConfig print the router's routes as JSON and
+// exit instead of listening. Set it to "1" (or "-") for stdout, or to a file
+// path. Used by `largo route:list`.
+const RouteDumpEnv = "LARGO_ROUTE_DUMP"
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+    Method     string   `json:"method"`
+    Pattern    string   `json:"pattern"`
+    Name       string   `json:"name,omitempty"`
+    Middleware []string `json:"middleware,omitempty"`
+}
+
+// Routes lists the routes registered on the underlying mux, in chi's walk
+// order, with their names and the middleware applied to them (outermost first).
+func (r *Router) Routes() []RouteInfo {
+    root := r.root()
+    byKey := make(map[string]*Route, len(root.routes))
+    for _, rt := range root.routes {
+        byKey[rt.method+" "+rt.pattern] = rt
+    }
+    var out []RouteInfo
+    _ = chi.Walk(root.mux, func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+        info := RouteInfo{Method: method, Pattern: pattern}
+        if rt, ok := byKey[method+" "+pattern]; ok {
+            info.Name = rt.name
+            info.Middleware = rt.router.middlewareNames()
+        }
+        out = append(out, info)
+        return nil
+    })
+    return out
+}
+
+// middlewareNames returns the names of the middleware applied by r and its
+// ancestors, outermost first.
+func (r *Router) middlewareNames() []string {
+    var chain []*Router
+    for rr := r; rr != nil; rr = rr.parent {
+        chain = append(chain, rr)
+    }
+    var names []string
+    for i := len(chain) - 1; i >= 0; i-- {
+        for _, mw := range chain[i].middlewares {
+            names = append(names, funcName(mw))
+        }
+    }
+    return names
+}
+
+// funcName derives a readable name such as "httpx.RequestID" from a
+// middleware closure.
+func funcName(fn any) string {
+    f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+    if f == nil {
+        return "?"
+    }
+    name := path.Base(f.Name())
+    for {
+        i := strings.LastIndex(name, ".func")
+        if i < 0 || strings.Trim(name[i+5:], "0123456789.") != "" {
+            break
+        }
+        name = name[:i]
+    }
+    return name
+}
+
+// dumpRoutes writes h's routes when RouteDumpEnv is set. It reports whether
+// the dump mode was active so callers can skip serving.
+func dumpRoutes(h http.Handler) (bool, error) {
+    dest := os.Getenv(RouteDumpEnv)
+    if dest == "" {
+        return false, nil
+    }
+    rl, ok := h.(interface{ Routes() []RouteInfo })
+    if !ok {
+        return true, fmt.Errorf("%s is set but handler %T does not expose Routes()", RouteDumpEnv, h)
+    }
+    var w io.Writer = os.Stdout
+    if dest != "1" && dest != "-" {
+        f, err := os.Create(dest)
+        if err != nil {
+            return true, err
+        }
+        defer f.Close()
+        w = f
+    }
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return true, enc.Encode(rl.Routes())
+}
@@ -1,16 +1,52 @@
 package httpx
 
 import (
+    "context"
+    "errors"
     "fmt"
+    "log/slog"
     "net/http"
     "os"
+    "os/signal"
+    "sync"
+    "sync/atomic"
+    "syscall"
     "time"
 
+    "golang.org/x/net/http2"
+    "golang.org/x/net/http2/h2c"
+
     "github.com/MohammedMogeab/largo/pkg/config"
 )
 
-// Serve starts an HTTP server with sane timeouts.
+// DefaultShutdownTimeout bounds draining for Serve/ServeEnv.
+const DefaultShutdownTimeout = 15 * time.Second
+
+var (
+    shutdownMu    sync.Mutex
+    shutdownHooks []func(context.Context) error
+    shuttingDown  atomic.Bool
+)
+
+// ShuttingDown reports whether a shutdown signal has been received. Readiness
+// checks use it to fail while the server drains.
+func ShuttingDown() bool { return shuttingDown.Load() }
+
+// OnShutdown registers fn to run once the server has stopped accepting
+// connections and drained in-flight requests, e.g. to close DB pools or flush
+// logs. Hooks run in reverse registration order, like defers.
+func OnShutdown(fn func(ctx context.Context) error) {
+    shutdownMu.Lock()
+    defer shutdownMu.Unlock()
+    shutdownHooks = append(shutdownHooks, fn)
+}
+
+// Serve starts an HTTP server with sane timeouts. It shuts down gracefully on
+// SIGINT/SIGTERM and returns nil once drained.
 func Serve(addr string, h http.Handler) error {
+    if ok, err := dumpRoutes(h); ok {
+        return err
+    }
     srv := &http.Server{
         Addr:              addr,
         Handler:           h,
@@ -20,7 +56,7 @@ func Serve(addr string, h http.Handler) error {
         IdleTimeout:       60 * time.Second,
         MaxHeaderBytes:    1 << 20, // 1MB
     }
-    return srv.ListenAndServe()
+    return run(0, DefaultShutdownTimeout, listener{srv, srv.ListenAndServe})
 }
 
 // ServeEnv reads PORT from env (default 8080) and serves the handler.
@@ -32,8 +68,16 @@ func ServeEnv(h http.Handler) error {
     return Serve(fmt.Sprintf(":%s", port), h)
 }
 
-// ServeConfig starts an HTTP server using values from Config.
+// ServeConfig starts an HTTP server using values from Config. Like Serve it
+// drains on SIGINT/SIGTERM, waiting up to HTTP_SHUTDOWN_TIMEOUT.
+//
+// With TLS_CERT_FILE/TLS_KEY_FILE set it serves HTTPS (HTTP/2 negotiated via
+// ALPN), optionally requiring client certs and redirecting a plaintext port.
+// Otherwise HTTP_H2C=true enables cleartext HTTP/2 for use behind proxies.
 func ServeConfig(cfg *config.Config, h http.Handler) error {
+    if ok, err := dumpRoutes(h); ok {
+        return err
+    }
     addr := fmt.Sprintf(":%d", cfg.App.Port)
     srv := &http.Server{
         Addr:              addr,
@@ -44,5 +88,107 @@ func ServeConfig(cfg *config.Config, h http.Handler) error {
         IdleTimeout:       cfg.HTTP.IdleTimeout(),
         MaxHeaderBytes:    cfg.HTTP.MaxHeaderBytes,
     }
-    return srv.ListenAndServe()
+    if !cfg.TLS.Enabled() {
+        if cfg.HTTP.H2C {
+            srv.Handler = h2c.NewHandler(h, &http2.Server{IdleTimeout: cfg.HTTP.IdleTimeout()})
+        }
+        return run(cfg.HTTP.ShutdownDelay(), cfg.HTTP.ShutdownTimeout(), listener{srv, srv.ListenAndServe})
+    }
+
+    tlsCfg, err := TLSConfig(cfg.TLS)
+    if err != nil {
+        return err
+    }
+    srv.TLSConfig = tlsCfg
+    ls := []listener{{srv, func() error { return srv.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile) }}}
+    if cfg.TLS.RedirectPort > 0 {
+        rs := &http.Server{
+            Addr:              fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+            Handler:           RedirectHTTPS(cfg.App.Port),
+            ReadHeaderTimeout: cfg.HTTP.ReadTimeout(),
+            IdleTimeout:       cfg.HTTP.IdleTimeout(),
+        }
+        ls = append(ls, listener{rs, rs.ListenAndServe})
+    }
+    return run(cfg.HTTP.ShutdownDelay(), cfg.HTTP.ShutdownTimeout(), ls...)
+}
+
+// listener pairs a server with the call that starts it.
+type listener struct {
+    srv    *http.Server
+    listen func() error
+}
+
+// run starts all listeners and waits for one to fail or for SIGINT/SIGTERM.
+// On a signal it flags ShuttingDown and keeps serving for delay (so load
+// balancers observe failing readiness), then drains every server within
+// timeout and runs the OnShutdown hooks.
+func run(delay, timeout time.Duration, ls ...listener) error {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    errCh := make(chan error, len(ls))
+    for _, l := range ls {
+        l := l
+        go func() { errCh <- l.listen() }()
+        slog.Info("server.start", slog.String("addr", l.srv.Addr))
+    }
+
+    var errs []error
+    select {
+    case err := <-errCh:
+        if !errors.Is(err, http.ErrServerClosed) {
+            errs = append(errs, err)
+        }
+    case <-ctx.Done():
+        stop() // a second signal terminates immediately
+        shuttingDown.Store(true)
+        slog.Info("server.shutdown", slog.Duration("delay", delay), slog.Duration("timeout", timeout))
+        if delay > 0 {
+            select {
+            case <-time.After(delay):
+            case err := <-errCh:
+                if !errors.Is(err, http.ErrServerClosed) {
+                    errs = append(errs, err)
+                }
+            }
+        }
+    }
+
+    sctx, cancel := context.WithTimeout(context.Background(), timeout)
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    for _, l := range ls {
+        wg.Add(1)
+        go func(srv *http.Server) {
+            defer wg.Done()
+            if err := srv.Shutdown(sctx); err != nil {
+                _ = srv.Close()
+                mu.Lock()
+                errs = append(errs, fmt.Errorf("graceful shutdown %s: %w", srv.Addr, err))
+                mu.Unlock()
+            }
+        }(l.srv)
+    }
+    wg.Wait()
+    cancel()
+
+    hctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+    errs = append(errs, runShutdownHooks(hctx))
+    return errors.Join(errs...)
+}
+
+func runShutdownHooks(ctx context.Context) error {
+    shutdownMu.Lock()
+    hooks := shutdownHooks
+    shutdownMu.Unlock()
+    var errs []error
+    for i := len(hooks) - 1; i >= 0; i-- {
+        if err := hooks[i](ctx); err != nil {
+            slog.Error("server.shutdown_hook", slog.String("error", err.Error()))
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
 }
@@ -0,0 +1,62 @@
+package httpx
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "net"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+
+    "github.com/MohammedMogeab/largo/pkg/config"
+)
+
+// TLSConfig builds a *tls.Config from config: minimum version and, when
+// ClientCAFile is set, mandatory client certificates verified against it.
+// Certificates themselves are loaded by ListenAndServeTLS.
+func TLSConfig(c config.TLSConfig) (*tls.Config, error) {
+    tc := &tls.Config{}
+    switch c.MinVersion {
+    case "", "1.2":
+        tc.MinVersion = tls.VersionTLS12
+    case "1.3":
+        tc.MinVersion = tls.VersionTLS13
+    default:
+        return nil, fmt.Errorf("unsupported TLS_MIN_VERSION %q (use 1.2 or 1.3)", c.MinVersion)
+    }
+    if c.ClientCAFile != "" {
+        pem, err := os.ReadFile(c.ClientCAFile)
+        if err != nil {
+            return nil, fmt.Errorf("read TLS_CLIENT_CA_FILE: %w", err)
+        }
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) {
+            return nil, fmt.Errorf("TLS_CLIENT_CA_FILE %s contains no PEM certificates", c.ClientCAFile)
+        }
+        tc.ClientCAs = pool
+        tc.ClientAuth = tls.RequireAndVerifyClientCert
+    }
+    return tc, nil
+}
+
+// RedirectHTTPS returns a handler that permanently redirects requests to the
+// same host and path over HTTPS on httpsPort.
+func RedirectHTTPS(httpsPort int) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        host := r.Host
+        if h, _, err := net.SplitHostPort(host); err == nil {
+            host = h
+        } else {
+            host = strings.Trim(host, "[]") // IPv6 literal without a port
+        }
+        switch {
+        case httpsPort != 443:
+            host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+        case strings.Contains(host, ":"):
+            host = "[" + host + "]"
+        }
+        http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+    })
+}
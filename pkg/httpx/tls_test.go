@@ -0,0 +1,36 @@
+package httpx
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestRedirectHTTPS(t *testing.T) {
+    tests := []struct {
+        host string
+        port int
+        want string
+    }{
+        {"example.com", 443, "https://example.com/a?b=1"},
+        {"example.com:8080", 443, "https://example.com/a?b=1"},
+        {"example.com:8080", 8443, "https://example.com:8443/a?b=1"},
+        {"[::1]", 443, "https://[::1]/a?b=1"},
+        {"[::1]", 8443, "https://[::1]:8443/a?b=1"},
+        {"[::1]:8080", 443, "https://[::1]/a?b=1"},
+        {"[::1]:8080", 8443, "https://[::1]:8443/a?b=1"},
+        {"[2001:db8::1]", 8443, "https://[2001:db8::1]:8443/a?b=1"},
+    }
+    for _, tt := range tests {
+        req := httptest.NewRequest(http.MethodGet, "/a?b=1", nil)
+        req.Host = tt.host
+        rec := httptest.NewRecorder()
+        RedirectHTTPS(tt.port).ServeHTTP(rec, req)
+        if rec.Code != http.StatusPermanentRedirect {
+            t.Errorf("%s -> %d: status = %d", tt.host, tt.port, rec.Code)
+        }
+        if got := rec.Header().Get("Location"); got != tt.want {
+            t.Errorf("%s -> %d: Location = %q, want %q", tt.host, tt.port, got, tt.want)
+        }
+    }
+}
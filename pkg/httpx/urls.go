@@ -0,0 +1,89 @@
+package httpx
+
+import (
+    "fmt"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// buildURL substitutes chi params ({id}, {id:[0-9]+}, trailing *) in pattern
+// with values from key/value pairs. Leftover pairs become the query string.
+func buildURL(pattern string, pairs []string) (string, error) {
+    if len(pairs)%2 != 0 {
+        return "", fmt.Errorf("httpx: odd number of URL params for %s", pattern)
+    }
+    params := make(map[string]string, len(pairs)/2)
+    order := make([]string, 0, len(pairs)/2)
+    for i := 0; i < len(pairs); i += 2 {
+        if _, dup := params[pairs[i]]; !dup {
+            order = append(order, pairs[i])
+        }
+        params[pairs[i]] = pairs[i+1]
+    }
+
+    var b strings.Builder
+    used := make(map[string]bool, len(params))
+    for i := 0; i < len(pattern); i++ {
+        switch ch := pattern[i]; ch {
+        case '{':
+            end := closingBrace(pattern, i)
+            if end < 0 {
+                return "", fmt.Errorf("httpx: malformed pattern %s", pattern)
+            }
+            key, rexp := pattern[i+1:end], ""
+            if k, re, ok := strings.Cut(key, ":"); ok {
+                key, rexp = k, re
+            }
+            v, ok := params[key]
+            if !ok {
+                return "", fmt.Errorf("httpx: missing URL param %q for %s", key, pattern)
+            }
+            if rexp != "" {
+                if ok, err := regexp.MatchString("^(?:"+rexp+")$", v); err == nil && !ok {
+                    return "", fmt.Errorf("httpx: URL param %q=%q does not match %s", key, v, rexp)
+                }
+            }
+            used[key] = true
+            b.WriteString(url.PathEscape(v))
+            i = end
+        case '*':
+            // catch-all: value is inserted as-is (may contain slashes)
+            if v, ok := params["*"]; ok {
+                used["*"] = true
+                b.WriteString(strings.TrimPrefix(v, "/"))
+            }
+        default:
+            b.WriteByte(ch)
+        }
+    }
+
+    q := url.Values{}
+    for _, k := range order {
+        if !used[k] {
+            q.Set(k, params[k])
+        }
+    }
+    if len(q) > 0 {
+        return b.String() + "?" + q.Encode(), nil
+    }
+    return b.String(), nil
+}
+
+// closingBrace finds the brace closing the one at i, allowing nested braces
+// inside regexps such as {code:[a-z]{2}}.
+func closingBrace(s string, i int) int {
+    depth := 0
+    for j := i; j < len(s); j++ {
+        switch s[j] {
+        case '{':
+            depth++
+        case '}':
+            depth--
+            if depth == 0 {
+                return j
+            }
+        }
+    }
+    return -1
+}
@@ -0,0 +1,125 @@
+package xerr
+
+import (
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync/atomic"
+)
+
+// Format selects how errors are rendered.
+type Format int32
+
+const (
+    // FormatEnvelope renders the unified Envelope as application/json (default).
+    FormatEnvelope Format = iota
+    // FormatProblem renders RFC 9457 problem details as application/problem+json.
+    FormatProblem
+    // FormatNegotiate renders problem details when the request's Accept header
+    // prefers application/problem+json, and the Envelope otherwise.
+    FormatNegotiate
+)
+
+var (
+    format      atomic.Int32
+    problemBase atomic.Value // string
+)
+
+// SetFormat selects the global error format. Call it at startup.
+func SetFormat(f Format) { format.Store(int32(f)) }
+
+// CurrentFormat returns the global error format.
+func CurrentFormat() Format { return Format(format.Load()) }
+
+// SetProblemTypeBase sets the URI prefix used for the problem "type" member,
+// e.g. "https://api.example.com/problems/" yields ".../problems/not_found".
+// When unset, type is "about:blank".
+func SetProblemTypeBase(base string) { problemBase.Store(base) }
+
+// Problem is an RFC 9457 problem details object. Code, Details and RequestID
+// are extension members.
+type Problem struct {
+    Type      string `json:"type"`
+    Title     string `json:"title"`
+    Status    int    `json:"status"`
+    Detail    string `json:"detail,omitempty"`
+    Instance  string `json:"instance,omitempty"`
+    Code      string `json:"code,omitempty"`
+    Details   any    `json:"details,omitempty"`
+    RequestID string `json:"request_id,omitempty"`
+}
+
+// Problem converts e to problem details; instance is usually the request path.
+func (e *HTTPError) Problem(reqID, instance string) Problem {
+    typ := "about:blank"
+    if base, _ := problemBase.Load().(string); base != "" {
+        typ = base + e.Code
+    }
+    return Problem{
+        Type:      typ,
+        Title:     nz(http.StatusText(e.Status), e.Code),
+        Status:    e.Status,
+        Detail:    nz(e.Message, http.StatusText(e.Status)),
+        Instance:  instance,
+        Code:      e.Code,
+        Details:   e.Details,
+        RequestID: reqID,
+    }
+}
+
+// RenderRequest writes e in the global format, negotiating against r's
+// Accept header when the format is FormatNegotiate.
+func (e *HTTPError) RenderRequest(w http.ResponseWriter, r *http.Request, reqID string) {
+    if !wantsProblem(r) {
+        e.Render(w, reqID)
+        return
+    }
+    instance := ""
+    if r != nil {
+        instance = r.URL.Path
+    }
+    w.Header().Set("Content-Type", "application/problem+json")
+    writeBody(w, e.Status, e.Problem(reqID, instance))
+}
+
+// WriteRequest is Write with format negotiation, see RenderRequest.
+func WriteRequest(w http.ResponseWriter, r *http.Request, reqID string, err error) {
+    asHTTPError(err).RenderRequest(w, r, reqID)
+}
+
+func wantsProblem(r *http.Request) bool {
+    switch CurrentFormat() {
+    case FormatProblem:
+        return true
+    case FormatNegotiate:
+        return r != nil && prefersProblem(r.Header.Get("Accept"))
+    default:
+        return false
+    }
+}
+
+// prefersProblem reports whether accept ranks application/problem+json at
+// least as high as application/json.
+func prefersProblem(accept string) bool {
+    problemQ, jsonQ := -1.0, -1.0
+    for _, part := range strings.Split(accept, ",") {
+        mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+        q := 1.0
+        if v, ok := params["q"]; ok {
+            if f, err := strconv.ParseFloat(v, 64); err == nil {
+                q = f
+            }
+        }
+        switch mt {
+        case "application/problem+json":
+            problemQ = q
+        case "application/json":
+            jsonQ = q
+        }
+    }
+    return problemQ > 0 && problemQ >= jsonQ
+}
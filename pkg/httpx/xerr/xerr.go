@@ -2,6 +2,7 @@ package xerr
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
 )
 
@@ -13,48 +14,158 @@ type Envelope struct {
     RequestID string      `json:"request_id,omitempty"`
 }
 
+// HTTPError is an error that carries the HTTP status and envelope fields it
+// should be rendered with. It can wrap a cause (see Wrap) and be matched with
+// errors.As, or with errors.Is against another HTTPError of the same status
+// and code.
+type HTTPError struct {
+    Status  int
+    Code    string
+    Message string
+    Details any
+    Err     error
+}
+
+// New returns an HTTPError; an empty msg renders as the status text.
+func New(status int, code, msg string) *HTTPError {
+    return &HTTPError{Status: status, Code: code, Message: msg}
+}
+
+func (e *HTTPError) Error() string {
+    s := e.Code + ": " + nz(e.Message, http.StatusText(e.Status))
+    if e.Err != nil {
+        s += ": " + e.Err.Error()
+    }
+    return s
+}
+
+// Unwrap returns the wrapped cause, if any.
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// Is reports whether target is an HTTPError with the same status and code.
+func (e *HTTPError) Is(target error) bool {
+    t, ok := target.(*HTTPError)
+    return ok && t.Status == e.Status && t.Code == e.Code
+}
+
+// WithDetails returns a copy of e carrying details.
+func (e *HTTPError) WithDetails(details any) *HTTPError {
+    cp := *e
+    cp.Details = details
+    return &cp
+}
+
+// Wrap returns a copy of e with err as its cause. The cause is not rendered,
+// only kept for logging and errors.Is/As.
+func (e *HTTPError) Wrap(err error) *HTTPError {
+    cp := *e
+    cp.Err = err
+    return &cp
+}
+
+// Render writes e using the unified envelope, or as problem details when the
+// global format is FormatProblem (see SetFormat). Without a request there is
+// nothing to negotiate, so FormatNegotiate falls back to the envelope; use
+// RenderRequest where the request is at hand.
+func (e *HTTPError) Render(w http.ResponseWriter, reqID string) {
+    if CurrentFormat() == FormatProblem {
+        w.Header().Set("Content-Type", "application/problem+json")
+        writeBody(w, e.Status, e.Problem(reqID, ""))
+        return
+    }
+    writeJSON(w, e.Status, Envelope{Error: e.Code, Message: nz(e.Message, http.StatusText(e.Status)), Details: e.Details, RequestID: reqID})
+}
+
+// Write renders any error into the envelope: HTTPErrors (also when wrapped)
+// keep their status and code, everything else becomes a generic 500 so
+// internal messages don't leak to clients.
+func Write(w http.ResponseWriter, reqID string, err error) {
+    asHTTPError(err).Render(w, reqID)
+}
+
+func asHTTPError(err error) *HTTPError {
+    var he *HTTPError
+    if !errors.As(err, &he) {
+        he = ErrInternal("")
+    }
+    return he
+}
+
+// Constructors for common errors
+func ErrBadRequest(msg string) *HTTPError         { return New(http.StatusBadRequest, "bad_request", msg) }
+func ErrUnauthorized(msg string) *HTTPError       { return New(http.StatusUnauthorized, "unauthorized", msg) }
+func ErrForbidden(msg string) *HTTPError          { return New(http.StatusForbidden, "forbidden", msg) }
+func ErrNotFound(msg string) *HTTPError           { return New(http.StatusNotFound, "not_found", msg) }
+func ErrMethodNotAllowed(msg string) *HTTPError   { return New(http.StatusMethodNotAllowed, "method_not_allowed", msg) }
+func ErrConflict(msg string) *HTTPError           { return New(http.StatusConflict, "conflict", msg) }
+func ErrPreconditionFailed(msg string) *HTTPError { return New(http.StatusPreconditionFailed, "precondition_failed", msg) }
+func ErrPayloadTooLarge(msg string) *HTTPError    { return New(http.StatusRequestEntityTooLarge, "payload_too_large", msg) }
+func ErrRateLimited(msg string) *HTTPError        { return New(http.StatusTooManyRequests, "rate_limited", msg) }
+func ErrInternal(msg string) *HTTPError           { return New(http.StatusInternalServerError, "internal", msg) }
+func ErrTimeout(msg string) *HTTPError            { return New(http.StatusServiceUnavailable, "timeout", msg) }
+
+// StatusPageExpired is the non-standard 419 used (as in Laravel) for CSRF
+// token mismatches.
+const StatusPageExpired = 419
+
+// ErrCSRFMismatch returns a 419 for a missing or invalid CSRF token.
+func ErrCSRFMismatch(msg string) *HTTPError {
+    if msg == "" {
+        msg = "CSRF token mismatch"
+    }
+    return New(StatusPageExpired, "csrf_mismatch", msg)
+}
+
+// ErrValidation returns a 422 carrying field->message details.
+func ErrValidation(fields map[string]string) *HTTPError {
+    return New(http.StatusUnprocessableEntity, "validation_failed", "validation failed").WithDetails(fields)
+}
+
 func writeJSON(w http.ResponseWriter, code int, v any) {
     w.Header().Set("Content-Type", "application/json; charset=utf-8")
+    writeBody(w, code, v)
+}
+
+func writeBody(w http.ResponseWriter, code int, v any) {
     w.WriteHeader(code)
     _ = json.NewEncoder(w).Encode(v)
 }
 
 // Helpers for common errors
 func BadRequest(w http.ResponseWriter, reqID, msg string, details any) {
-    writeJSON(w, http.StatusBadRequest, Envelope{Error: "bad_request", Message: nz(msg, http.StatusText(http.StatusBadRequest)), Details: details, RequestID: reqID})
+    ErrBadRequest(msg).WithDetails(details).Render(w, reqID)
 }
 
 func Unauthorized(w http.ResponseWriter, reqID, msg string) {
-    writeJSON(w, http.StatusUnauthorized, Envelope{Error: "unauthorized", Message: nz(msg, http.StatusText(http.StatusUnauthorized)), RequestID: reqID})
+    ErrUnauthorized(msg).Render(w, reqID)
 }
 
 func Forbidden(w http.ResponseWriter, reqID, msg string) {
-    writeJSON(w, http.StatusForbidden, Envelope{Error: "forbidden", Message: nz(msg, http.StatusText(http.StatusForbidden)), RequestID: reqID})
+    ErrForbidden(msg).Render(w, reqID)
 }
 
 func NotFound(w http.ResponseWriter, reqID, msg string) {
-    writeJSON(w, http.StatusNotFound, Envelope{Error: "not_found", Message: nz(msg, http.StatusText(http.StatusNotFound)), RequestID: reqID})
+    ErrNotFound(msg).Render(w, reqID)
 }
 
 func MethodNotAllowed(w http.ResponseWriter, reqID, msg string) {
     // 405 mapping (not listed in minimal set) -> use explicit code
-    writeJSON(w, http.StatusMethodNotAllowed, Envelope{Error: "method_not_allowed", Message: nz(msg, http.StatusText(http.StatusMethodNotAllowed)), RequestID: reqID})
+    ErrMethodNotAllowed(msg).Render(w, reqID)
 }
 
 func ValidationFailed(w http.ResponseWriter, reqID string, fields map[string]string) {
-    writeJSON(w, http.StatusUnprocessableEntity, Envelope{Error: "validation_failed", Message: "validation failed", Details: fields, RequestID: reqID})
+    ErrValidation(fields).Render(w, reqID)
 }
 
 func RateLimited(w http.ResponseWriter, reqID, msg string) {
-    writeJSON(w, http.StatusTooManyRequests, Envelope{Error: "rate_limited", Message: nz(msg, http.StatusText(http.StatusTooManyRequests)), RequestID: reqID})
+    ErrRateLimited(msg).Render(w, reqID)
 }
 
 func Internal(w http.ResponseWriter, reqID, msg string) {
-    writeJSON(w, http.StatusInternalServerError, Envelope{Error: "internal", Message: nz(msg, http.StatusText(http.StatusInternalServerError)), RequestID: reqID})
+    ErrInternal(msg).Render(w, reqID)
 }
 
 func nz(s, def string) string {
     if s == "" { return def }
     return s
 }
-
@@ -0,0 +1,73 @@
+// Package logx builds slog loggers from config.LogConfig.
+package logx
+
+import (
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/MohammedMogeab/largo/pkg/config"
+)
+
+// New returns a logger for cfg: LOG_LEVEL (debug|info|warn|error), LOG_FORMAT
+// (text|json), LOG_SOURCE (add file:line) and LOG_FILE (stdout, stderr or a
+// path opened for append; empty means stdout).
+func New(cfg config.LogConfig) (*slog.Logger, error) {
+    level, err := ParseLevel(cfg.Level)
+    if err != nil {
+        return nil, err
+    }
+    w, err := output(cfg.File)
+    if err != nil {
+        return nil, err
+    }
+    opts := &slog.HandlerOptions{Level: level, AddSource: cfg.Source}
+    switch strings.ToLower(cfg.Format) {
+    case "", "text":
+        return slog.New(slog.NewTextHandler(w, opts)), nil
+    case "json":
+        return slog.New(slog.NewJSONHandler(w, opts)), nil
+    default:
+        return nil, fmt.Errorf("logx: unknown LOG_FORMAT %q (want text or json)", cfg.Format)
+    }
+}
+
+// Setup builds the logger with New and installs it as slog's default, so
+// package-level slog calls (server lifecycle, config) use it too.
+func Setup(cfg config.LogConfig) (*slog.Logger, error) {
+    l, err := New(cfg)
+    if err != nil {
+        return nil, err
+    }
+    slog.SetDefault(l)
+    return l, nil
+}
+
+// ParseLevel parses debug, info, warn or error; empty means info.
+func ParseLevel(s string) (slog.Level, error) {
+    var l slog.Level
+    if s == "" {
+        return slog.LevelInfo, nil
+    }
+    if err := l.UnmarshalText([]byte(s)); err != nil {
+        return 0, fmt.Errorf("logx: unknown LOG_LEVEL %q", s)
+    }
+    return l, nil
+}
+
+func output(file string) (io.Writer, error) {
+    switch strings.ToLower(file) {
+    case "", "stdout":
+        return os.Stdout, nil
+    case "stderr":
+        return os.Stderr, nil
+    }
+    if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+        return nil, err
+    }
+    // left open for the life of the process
+    return os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}